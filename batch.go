@@ -0,0 +1,279 @@
+package revolver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 256 * 1024
+	defaultBatchInterval = 100 * time.Millisecond
+)
+
+// Batch specifies the buffering of a BatchWriter.
+type Batch struct {
+	Size     int           // optional buffered bytes before writers wait for the flusher, defaults to 256 KiB
+	Interval time.Duration // optional max time a record stays buffered, defaults to 100ms
+}
+
+// BatchWriter buffers the records of concurrent writers and writes them in large batches from a
+// single flusher goroutine, which also rotates the files. Writers only hold the lock to copy their
+// record, so they don't wait for write syscalls or rotations. Every Write is treated as one record,
+// records are never split across files and are written in the order of their writes.
+// Write errors of the flusher are returned by the next Flush or Close.
+// BatchWriter implements ContextWriter.
+type BatchWriter struct {
+	w    *Writer
+	size int
+
+	lock     *sync.Mutex // synchronizes the buffer
+	space    *sync.Cond  // signals writers waiting for space
+	buf      []byte
+	ends     []int // end offset of every record in buf
+	err      error // first flush error not returned yet
+	closed   bool
+	flushing int // bytes of the records written by the flusher
+
+	closeErr error
+	shutdown chan struct{} // closed when all records are written and the file is closed
+
+	flushLock *sync.Mutex // synchronizes flushes and the spare buffer
+	spare     []byte
+	spareEnds []int
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBatch returns a BatchWriter which writes revolving files as specified by the given conf.
+func NewBatch(conf Conf, batch Batch) (*BatchWriter, error) {
+	if batch.Size < 0 {
+		return nil, invalidConf("Batch.Size", "must be >= 0")
+	}
+	if batch.Interval < 0 {
+		return nil, invalidConf("Batch.Interval", "must be >= 0")
+	}
+	if batch.Size == 0 {
+		batch.Size = defaultBatchSize
+	}
+	if batch.Interval == 0 {
+		batch.Interval = defaultBatchInterval
+	}
+	if err := ValidConf(conf); err != nil {
+		return nil, err
+	}
+	w, err := newWriter(clean(conf))
+	if err != nil {
+		return nil, err
+	}
+	b := &BatchWriter{
+		w:         w,
+		size:      batch.Size,
+		lock:      &sync.Mutex{},
+		buf:       make([]byte, 0, batch.Size),
+		flushLock: &sync.Mutex{},
+		spare:     make([]byte, 0, batch.Size),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		shutdown:  make(chan struct{}),
+	}
+	b.space = sync.NewCond(b.lock)
+	go b.run(batch.Interval)
+	return b, nil
+}
+
+// Write copies p into the buffer, it waits for the flusher if the buffer is full.
+func (b *BatchWriter) Write(p []byte) (n int, err error) {
+	return b.WriteContext(context.Background(), p)
+}
+
+// WriteContext is like Write, but stops waiting for the flusher once ctx is done.
+// In that case p is not buffered.
+func (b *BatchWriter) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			b.lock.Lock()
+			b.space.Broadcast()
+			b.lock.Unlock()
+		})
+		defer stop()
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for !b.closed && ctx.Err() == nil && len(b.buf) > 0 && len(b.buf)+len(p) > b.size {
+		b.notify()
+		b.space.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("revolver, batch, %w, %d bytes not written", err, len(p))
+	}
+	if b.closed {
+		return 0, ErrClosed
+	}
+	b.buf = append(b.buf, p...)
+	b.ends = append(b.ends, len(b.buf))
+	if len(b.buf) >= b.size {
+		b.notify()
+	}
+	return len(p), nil
+}
+
+// notify wakes the flusher if it is not woken already.
+func (b *BatchWriter) notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *BatchWriter) run(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.wake:
+		case <-ticker.C:
+		case <-b.stop:
+			return
+		}
+		b.flush()
+	}
+}
+
+// flush swaps the buffers and writes the records of the full one.
+func (b *BatchWriter) flush() {
+	b.flushLock.Lock()
+	defer b.flushLock.Unlock()
+
+	b.lock.Lock()
+	buf, ends := b.buf, b.ends
+	b.buf, b.ends = b.spare[:0], b.spareEnds[:0]
+	b.flushing = len(buf)
+	b.space.Broadcast()
+	b.lock.Unlock()
+
+	if len(ends) == 0 {
+		b.spare, b.spareEnds = buf, ends
+		return
+	}
+	err := b.w.writeBatch(buf, ends)
+	b.spare, b.spareEnds = buf, ends
+
+	b.lock.Lock()
+	b.flushing = 0
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	b.lock.Unlock()
+}
+
+// Flush writes all buffered records and returns the first write error not returned yet.
+func (b *BatchWriter) Flush() error {
+	b.flush()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	err := b.err
+	b.err = nil
+	return err
+}
+
+// Close stops the flusher, writes all buffered records and closes the current file.
+// Writing to a closed BatchWriter returns ErrClosed. Calling Close again waits until the
+// writer is closed and returns the same error.
+func (b *BatchWriter) Close() error {
+	return b.Shutdown(context.Background())
+}
+
+// Shutdown is like Close, but returns once ctx is done. In that case the buffered records are
+// written and the file is closed in the background and the returned error tells how many bytes
+// are not written yet.
+func (b *BatchWriter) Shutdown(ctx context.Context) error {
+	b.lock.Lock()
+	if !b.closed {
+		b.closed = true
+		b.space.Broadcast()
+		go b.close()
+	}
+	b.lock.Unlock()
+
+	select {
+	case <-b.shutdown:
+		return b.closeErr
+	case <-ctx.Done():
+		b.lock.Lock()
+		pending := len(b.buf) + b.flushing
+		b.lock.Unlock()
+		return fmt.Errorf("revolver, batch, shutdown, %w, %d bytes not written yet", ctx.Err(), pending)
+	}
+}
+
+// close stops the flusher, writes the buffered records and closes the file.
+func (b *BatchWriter) close() {
+	defer close(b.shutdown)
+	close(b.stop)
+	<-b.done
+	err := b.Flush()
+	if closeErr := b.w.Close(); err == nil {
+		err = closeErr
+	}
+	b.closeErr = err
+}
+
+// writeBatch writes the records in buf ending at ends. Consecutive records which fit into the
+// current file are written at once. With redactions, deduplication or a limit every record is
+// written on its own. The first error is returned after all records are written.
+func (l *Writer) writeBatch(buf []byte, ends []int) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.stopped != nil {
+		return l.stopped
+	}
+
+	var first error
+	keep := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+	if l.redact != nil || l.dedup != nil || l.limit != nil {
+		start := 0
+		for _, end := range ends {
+			_, err := l.record(buf[start:end], time.Now())
+			keep(err)
+			start = end
+		}
+		return first
+	}
+
+	full := func(size int) bool {
+		return l.file == nil || l.size+l.sizeOf(size) > l.maxBytes
+	}
+	start, last := 0, 0
+	for _, end := range ends {
+		if last > start && full(end-start) {
+			keep(l.writeChunk(buf[start:last]))
+			start = last
+		}
+		if last == start && full(end-start) {
+			keep(l.writeChunk(buf[start:end])) // rotates
+			start = end
+		}
+		last = end
+	}
+	if last > start {
+		keep(l.writeChunk(buf[start:last]))
+	}
+	return first
+}
+
+func (l *Writer) writeChunk(p []byte) error {
+	start := time.Now()
+	n, err := l.write(p)
+	l.report(n, err, start)
+	return err
+}
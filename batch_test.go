@@ -0,0 +1,223 @@
+package revolver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchWriter(t *testing.T) {
+	var tests = []struct {
+		batch    Batch
+		maxBytes int
+		dedup    *Dedup
+		files    int
+	}{
+		{batch: Batch{}, maxBytes: 1000, files: 1},
+		{batch: Batch{Size: 16}, maxBytes: 1000, files: 1},
+		{batch: Batch{Size: 64, Interval: time.Millisecond}, maxBytes: 40, files: 5},
+		{batch: Batch{Size: 64}, maxBytes: 40, dedup: &Dedup{}, files: 5},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. batch: %+v max bytes: %d", index, test.batch, test.maxBytes), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			seq := 0
+			w, err := NewBatch(Conf{
+				Dir:      "test",
+				Prefix:   "log_",
+				Middle:   func() string { seq++; return fmt.Sprintf("%03d", seq) },
+				MaxFiles: 100,
+				MaxBytes: test.maxBytes,
+				Dedup:    test.dedup,
+			}, test.batch)
+			logErrAt(err, index, t)
+			var exp []string
+			for record := 0; record < 20; record++ {
+				line := fmt.Sprintf("record %02d\n", record)
+				exp = append(exp, line)
+				n, err := w.Write([]byte(line))
+				logErrAt(err, index, t)
+				if n != len(line) {
+					t.Errorf("%d. exp n: %d got: %d", index, len(line), n)
+				}
+			}
+			logErrAt(w.Close(), index, t)
+
+			if got := readAll(t); got != strings.Join(exp, "") {
+				t.Errorf("%d. exp content: %q got: %q", index, strings.Join(exp, ""), got)
+			}
+			count, err := fileCount("test", "log_")
+			logErrAt(err, index, t)
+			if count != test.files {
+				t.Errorf("%d. exp files: %d got: %d", index, test.files, count)
+			}
+			if _, err := w.Write([]byte("closed\n")); err != ErrClosed {
+				t.Errorf("%d. exp closed err got: %v", index, err)
+			}
+		})
+	}
+}
+
+func TestBatchWriterErr(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := NewBatch(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 4}, Batch{})
+	logErr(err, t)
+	_, err = w.Write([]byte("too large"))
+	logErr(err, t)
+	exp := "revolver, write too large, 9 bytes over max file size 4"
+	if err := w.Flush(); errStr(err) != exp {
+		t.Errorf("exp err: '%s' got: '%v'", exp, err)
+	}
+	logErr(w.Flush(), t)
+
+	// a flush error is kept for Flush or Close and the next record is written
+	_, err = w.Write([]byte("too large"))
+	logErr(err, t)
+	w.flush()
+	_, err = w.Write([]byte("next"))
+	logErr(err, t)
+	if err := w.Close(); errStr(err) != exp {
+		t.Errorf("exp err: '%s' got: '%v'", exp, err)
+	}
+	if got := readAll(t); got != "next" {
+		t.Errorf("exp content: %q got: %q", "next", got)
+	}
+
+	if _, err := NewBatch(DefaultConf(), Batch{Size: -1}); errStr(err) != "revolver conf.Batch.Size must be >= 0" {
+		t.Errorf("exp batch err got: %v", err)
+	}
+}
+
+func TestBatchWriterShutdown(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	seq := 0
+	rotating := make(chan struct{})
+	w, err := NewBatch(Conf{
+		Dir:    "test",
+		Prefix: "log_",
+		Middle: func() string {
+			if seq++; seq > 1 {
+				<-rotating
+			}
+			return strconv.Itoa(seq)
+		},
+		MaxFiles: 10,
+		MaxBytes: 8,
+	}, Batch{Size: 8, Interval: time.Hour})
+	logErr(err, t)
+	// the flusher writes the first file and blocks on the rotation with the next 8 bytes
+	for _, record := range []string{"1111", "2222", "3333", "4444", "5555", "6666"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := w.WriteContext(ctx, []byte(record))
+		cancel()
+		logErr(err, t)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	exp := "revolver, batch, context deadline exceeded, 4 bytes not written"
+	if _, err := w.WriteContext(ctx, []byte("7777")); errStr(err) != exp {
+		t.Errorf("exp err: '%s' got: '%v'", exp, err)
+	}
+	exp = "revolver, batch, shutdown, context deadline exceeded, 16 bytes not written yet"
+	if err := w.Shutdown(ctx); errStr(err) != exp {
+		t.Errorf("exp err: '%s' got: '%v'", exp, err)
+	}
+	close(rotating)
+	logErr(w.Close(), t)
+	if got := readAll(t); got != "111122223333444455556666" {
+		t.Errorf("exp content: %q got: %q", "111122223333444455556666", got)
+	}
+}
+
+func TestBatchWriterConcurrent(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	seq := 0
+	w, err := NewBatch(Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   func() string { seq++; return fmt.Sprintf("%04d", seq) },
+		MaxFiles: 1000,
+		MaxBytes: 1000,
+	}, Batch{Size: 512})
+	logErr(err, t)
+	writeConcurrent(t, w, 8, 200)
+	logErr(w.Close(), t)
+
+	lines := strings.Split(strings.TrimSuffix(readAll(t), "\n"), "\n")
+	if len(lines) != 8*200 {
+		t.Errorf("exp %d records got: %d", 8*200, len(lines))
+	}
+	last := map[string]int{}
+	for _, line := range lines {
+		parts := strings.Fields(line)
+		record, err := strconv.Atoi(parts[1])
+		logErr(err, t)
+		if prev, ok := last[parts[0]]; ok && record != prev+1 {
+			t.Fatalf("exp records of writer %s in order got: %d after %d", parts[0], record, prev)
+		}
+		last[parts[0]] = record
+	}
+}
+
+// writeConcurrent writes records with writers goroutines, every writer numbers its records.
+func writeConcurrent(t testing.TB, w io.Writer, writers, records int) {
+	wg := &sync.WaitGroup{}
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			for record := 0; record < records; record++ {
+				if _, err := w.Write([]byte(fmt.Sprintf("w%d %d ..............................\n", writer, record))); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(writer)
+	}
+	wg.Wait()
+}
+
+func BenchmarkConcurrentWrite(b *testing.B) {
+	conf := Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   func() string { return strconv.FormatInt(time.Now().UnixNano(), 10) },
+		MaxFiles: 3,
+		MaxBytes: 10 * 1024 * 1024,
+	}
+	writers := []struct {
+		name string
+		new  func() (io.WriteCloser, error)
+	}{
+		{name: "Writer", new: func() (io.WriteCloser, error) { return New(conf) }},
+		{name: "BatchWriter", new: func() (io.WriteCloser, error) { return NewBatch(conf, Batch{}) }},
+	}
+	for _, writer := range writers {
+		for _, concurrent := range []int{1, 8, 64} {
+			b.Run(fmt.Sprintf("%s/%d", writer.name, concurrent), func(b *testing.B) {
+				defer func() {
+					logBenchmarkErr(os.RemoveAll("test"), b)
+				}()
+				w, err := writer.new()
+				logBenchmarkErr(err, b)
+				b.ResetTimer()
+				writeConcurrent(b, w, concurrent, b.N/concurrent+1)
+				logBenchmarkErr(w.Close(), b)
+			})
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package revolver
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumMode specifies the checksums written for closed files.
+type ChecksumMode int
+
+const (
+	// ChecksumNone writes no checksums.
+	ChecksumNone ChecksumMode = iota
+	// ChecksumFile writes the SHA-256 digest of every closed file into a sidecar file.
+	ChecksumFile
+	// ChecksumChain additionally chains every digest with the digest of the previous file,
+	// so a removed or edited file in the middle of the chain is detected by Verify.
+	ChecksumChain
+)
+
+// checksumExt is appended to the file name to get the sidecar name.
+const checksumExt = ".sha256"
+
+// sidecar is the content of a checksum sidecar file.
+type sidecar struct {
+	file      string // base name of the checked file
+	digest    string
+	prev      string // base name of the previous file, chain only
+	prevChain string // chained digest of the previous file, chain only
+	chain     string // chained digest, chain only
+}
+
+// isSidecar reports whether name is a checksum or pin sidecar file.
+func isSidecar(name string) bool {
+	return strings.HasSuffix(name, checksumExt) || strings.HasSuffix(name, pinExt)
+}
+
+func fileDigest(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// chainDigest returns the digest of the previous chain digest and the file digest.
+func chainDigest(prevChain, digest string) string {
+	sum := sha256.Sum256([]byte(prevChain + digest))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeSidecar(name string, s sidecar) error {
+	content := fmt.Sprintf("file: %s\nsha256: %s\n", s.file, s.digest)
+	if s.chain != "" {
+		content += fmt.Sprintf("prev: %s\nprevchain: %s\nchain: %s\n", s.prev, s.prevChain, s.chain)
+	}
+	tmp := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+checksumExt)
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name+checksumExt)
+}
+
+func readSidecar(name string) (sidecar, error) {
+	file, err := os.Open(name + checksumExt)
+	if err != nil {
+		return sidecar{}, err
+	}
+	defer file.Close()
+	s := sidecar{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key := strings.SplitN(scanner.Text(), ": ", 2)
+		if len(key) != 2 {
+			continue
+		}
+		switch key[0] {
+		case "file":
+			s.file = key[1]
+		case "sha256":
+			s.digest = key[1]
+		case "prev":
+			s.prev = key[1]
+		case "prevchain":
+			s.prevChain = key[1]
+		case "chain":
+			s.chain = key[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return sidecar{}, err
+	}
+	if s.digest == "" {
+		return sidecar{}, fmt.Errorf("sidecar %s has no digest", name+checksumExt)
+	}
+	return s, nil
+}
+
+// newestSidecar returns the sidecar of the newest of the given files which has one.
+func newestSidecar(files []string) sidecar {
+	for index := len(files) - 1; index >= 0; index-- {
+		if s, err := readSidecar(files[index]); err == nil {
+			return s
+		}
+	}
+	return sidecar{}
+}
+
+// writeChecksum writes the sidecar of the closed file and remembers it as previous link of the chain.
+func (l *Writer) writeChecksum(name string) error {
+	digest, err := fileDigest(name)
+	if err != nil {
+		return fmt.Errorf("error on checksum, %w", err)
+	}
+	s := sidecar{file: filepath.Base(name), digest: digest}
+	if l.checksum == ChecksumChain {
+		s.prev, s.prevChain = l.prev.file, l.prev.chain
+		s.chain = chainDigest(l.prev.chain, digest)
+	}
+	if err := writeSidecar(name, s); err != nil {
+		return fmt.Errorf("error on checksum, %w", err)
+	}
+	l.prev = s
+	return nil
+}
+
+// Verify checks the checksums of all files with the given prefix in dir.
+// Every file must match the digest of its sidecar, only the newest file may miss a sidecar
+// because it is still written. If the sidecars are chained, the chain must be complete from
+// the oldest remaining file to the newest, so removed or edited files in the middle are detected.
+// Every sidecar stores the chain digest of its previous file, so the oldest file is checked too.
+// Without any file Verify returns an error. Use VerifyConf for partitioned or templated files.
+func Verify(dir, prefix string) error {
+	return verify(dir, prefixNaming{prefix: prefix})
+}
+
+// VerifyConf is like Verify, but checks the files of the writer with the given conf,
+// including the files in partitions or named by a template.
+func VerifyConf(conf Conf) error {
+	if err := ValidConf(conf); err != nil {
+		return err
+	}
+	conf = clean(conf)
+	n, err := newNaming(conf)
+	if err != nil {
+		return err
+	}
+	return verify(conf.Dir, n)
+}
+
+func verify(dir string, n naming) error {
+	files, err := n.files(dir)
+	if err != nil {
+		return fmt.Errorf("revolver, verify, %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("revolver, verify, no files in %s", dir)
+	}
+
+	sidecars := map[string]sidecar{}
+	var chained []string
+	for index, name := range files {
+		s, err := readSidecar(name)
+		if os.IsNotExist(err) && index == len(files)-1 {
+			break // still written
+		}
+		if err != nil {
+			return fmt.Errorf("revolver, verify, %s, %w", name, err)
+		}
+		digest, err := fileDigest(name)
+		if err != nil {
+			return fmt.Errorf("revolver, verify, %s, %w", name, err)
+		}
+		if digest != s.digest {
+			return fmt.Errorf("revolver, verify, %s, digest mismatch", name)
+		}
+		if s.chain != "" {
+			sidecars[filepath.Base(name)] = s
+			chained = append(chained, filepath.Base(name))
+		}
+	}
+	return verifyChain(sidecars, chained)
+}
+
+// verifyChain checks the chained digest of every file and follows the chain from the only file
+// whose previous file is missing.
+func verifyChain(sidecars map[string]sidecar, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	next := map[string]string{}
+	head := ""
+	for _, name := range names {
+		s := sidecars[name]
+		if chainDigest(s.prevChain, s.digest) != s.chain || (s.prev == "") != (s.prevChain == "") {
+			return fmt.Errorf("revolver, verify, %s, chain mismatch", name)
+		}
+		if _, ok := sidecars[s.prev]; !ok {
+			if head != "" {
+				return fmt.Errorf("revolver, verify, chain broken before %s", name)
+			}
+			head = name
+			continue
+		}
+		if _, ok := next[s.prev]; ok {
+			return fmt.Errorf("revolver, verify, chain forked after %s", s.prev)
+		}
+		next[s.prev] = name
+	}
+	if head == "" {
+		return fmt.Errorf("revolver, verify, chain has no start")
+	}
+	visited := 1
+	for name := head; ; visited++ {
+		child, ok := next[name]
+		if !ok {
+			break
+		}
+		if sidecars[child].prevChain != sidecars[name].chain {
+			return fmt.Errorf("revolver, verify, %s, chain mismatch with %s", name, child)
+		}
+		name = child
+	}
+	if visited != len(names) {
+		return fmt.Errorf("revolver, verify, chain incomplete %d of %d files linked", visited, len(names))
+	}
+	return nil
+}
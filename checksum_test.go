@@ -0,0 +1,199 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeChainedFiles writes count files with chained checksums over two writer runs.
+func writeChainedFiles(t *testing.T, count, maxFiles int) []string {
+	conf := Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   testCounterMiddle(),
+		Suffix:   ".txt",
+		MaxFiles: maxFiles,
+		MaxBytes: 4,
+		Checksum: ChecksumChain,
+	}
+	for run := 0; run < 2; run++ {
+		w, err := New(conf)
+		logErr(err, t)
+		for file := 0; file < count/2; file++ {
+			_, err := w.Write([]byte(fmt.Sprintf("%d%d..", run, file)))
+			logErr(err, t)
+			time.Sleep(10 * time.Millisecond) // distinct mod times
+		}
+		logErr(w.Close(), t)
+	}
+	files, err := prefixNaming{prefix: "log_"}.files("test")
+	logErr(err, t)
+	return files
+}
+
+func TestVerify(t *testing.T) {
+	var tests = []struct {
+		maxFiles int
+		tamper   func(files []string, t *testing.T)
+		err      string
+	}{
+		{maxFiles: 10},
+		{maxFiles: 3},
+		{
+			maxFiles: 10,
+			tamper: func(files []string, t *testing.T) {
+				logErr(os.Remove(files[len(files)-1]+checksumExt), t)
+			},
+		},
+		{
+			maxFiles: 10,
+			tamper: func(files []string, t *testing.T) {
+				logErr(ioutil.WriteFile(files[2], []byte("edit"), 0644), t)
+			},
+			err: "digest mismatch",
+		},
+		{
+			maxFiles: 10,
+			tamper: func(files []string, t *testing.T) {
+				logErr(os.Remove(files[2]), t)
+				logErr(os.Remove(files[2]+checksumExt), t)
+			},
+			err: "revolver, verify, chain broken before log_4.txt",
+		},
+		{
+			maxFiles: 10,
+			tamper: func(files []string, t *testing.T) {
+				logErr(ioutil.WriteFile(files[2], []byte("edit"), 0644), t)
+				s, err := readSidecar(files[2])
+				logErr(err, t)
+				prev, err := readSidecar(files[1])
+				logErr(err, t)
+				s.digest, err = fileDigest(files[2])
+				logErr(err, t)
+				s.chain = chainDigest(prev.chain, s.digest)
+				logErr(writeSidecar(files[2], s), t)
+			},
+			err: "revolver, verify, log_3.txt, chain mismatch with log_4.txt",
+		},
+		{
+			maxFiles: 3,
+			tamper: func(files []string, t *testing.T) {
+				logErr(ioutil.WriteFile(files[0], []byte("edit"), 0644), t)
+				s, err := readSidecar(files[0])
+				logErr(err, t)
+				s.digest, err = fileDigest(files[0])
+				logErr(err, t)
+				logErr(writeSidecar(files[0], s), t)
+			},
+			err: "revolver, verify, log_4.txt, chain mismatch",
+		},
+		{
+			maxFiles: 10,
+			tamper: func(files []string, t *testing.T) {
+				logErr(os.Remove(files[1]+checksumExt), t)
+			},
+			err: "no such file or directory",
+		},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. verify err: %s", index, test.err), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			files := writeChainedFiles(t, 6, test.maxFiles)
+			if len(files) != 6 && len(files) != test.maxFiles {
+				t.Fatalf("%d. unexpected files: %v", index, files)
+			}
+			if test.tamper != nil {
+				test.tamper(files, t)
+			}
+			err := Verify("test", "log_")
+			if !strings.Contains(errStr(err), test.err) || (test.err == "") != (err == nil) {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyConf(t *testing.T) {
+	var tests = []struct {
+		conf Conf
+	}{
+		{conf: Conf{Prefix: "log_", Middle: testMiddlePartFunc, Partition: "2006/01"}},
+		{conf: Conf{Template: "{yyyy}/{seq}.log"}},
+		{conf: Conf{Prefix: "log_", Middle: testMiddlePartFunc, Index: true, Staging: ".inprogress"}},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. verify conf: %+v", index, test.conf), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			conf := test.conf
+			conf.Dir, conf.MaxFiles, conf.MaxBytes, conf.Checksum = "test", 10, 4, ChecksumChain
+			if err := VerifyConf(conf); !strings.HasPrefix(errStr(err), "revolver, verify, ") {
+				t.Errorf("%d. exp err without files got: %v", index, err)
+			}
+			w, err := New(conf)
+			logErrAt(err, index, t)
+			for _, record := range []string{"1111", "2222", "3333"} {
+				_, err := w.Write([]byte(record))
+				logErrAt(err, index, t)
+				time.Sleep(10 * time.Millisecond) // distinct mod times
+			}
+			logErrAt(w.Close(), index, t)
+			logErrAt(VerifyConf(conf), index, t)
+			if err := Verify("test", "log_"); test.conf.Partition != "" && err == nil {
+				t.Errorf("%d. exp err for partitioned files got: %v", index, err)
+			}
+
+			n, err := newNaming(conf)
+			logErrAt(err, index, t)
+			files, err := n.files("test")
+			logErrAt(err, index, t)
+			logErrAt(ioutil.WriteFile(files[1], []byte("edit"), 0644), index, t)
+			if err := VerifyConf(conf); !strings.Contains(errStr(err), "digest mismatch") {
+				t.Errorf("%d. exp digest mismatch got: %v", index, err)
+			}
+		})
+	}
+}
+
+func TestChecksumFile(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	conf := Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 10, Checksum: ChecksumFile}
+	w, err := New(conf)
+	logErr(err, t)
+	_, err = w.Write([]byte("data"))
+	logErr(err, t)
+	logErr(w.Close(), t)
+
+	name := filepath.FromSlash("test/log_" + testMiddlePart)
+	s, err := readSidecar(name)
+	logErr(err, t)
+	// echo -n data | sha256sum
+	exp := "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+	if s.digest != exp || s.file != "log_"+testMiddlePart || s.chain != "" {
+		t.Errorf("exp sidecar digest: '%s' without chain got: %+v", exp, s)
+	}
+	count, err := fileCount("test", "log_")
+	logErr(err, t)
+	if count != 1 {
+		t.Errorf("exp sidecar not to be counted got: %d files", count)
+	}
+	logErr(Verify("test", "log_"), t)
+
+	w, err = New(conf)
+	logErr(err, t)
+	if _, err := os.Stat(name + checksumExt); !os.IsNotExist(err) {
+		t.Errorf("exp sidecar to be removed with its file got: %v", err)
+	}
+	logErr(w.Close(), t)
+}
@@ -1,7 +1,6 @@
 package revolver
 
 import (
-	"fmt"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -22,7 +21,7 @@ func DateStringMiddle() string {
 	return strings.Replace(time.Now().Format("02-01-2006-15:04:05"), ":", "_", -1)
 }
 
-// Conf is deprecated. Use NewQuick instead.
+// Conf is deprecated. Use NewWriter instead.
 // Conf holds the conf for the revolving file writer.
 type Conf struct {
 	Dir      string        // CAUTION all files in this dir with the Prefix will eventually be deleted
@@ -31,6 +30,77 @@ type Conf struct {
 	Suffix   string        // optional
 	MaxFiles int           // min 1
 	MaxBytes int           // min 1
+
+	// Template is optional and replaces Prefix, Middle and Suffix e. g. "{host}/{app}-{date}-{seq}.log".
+	// CAUTION all files below Dir matching the template will eventually be deleted.
+	// See NewTemplate for the supported placeholders.
+	Template string
+	Values   map[string]string // custom template placeholder values e. g. {"app": "api"}
+
+	// Partition is an optional UTC time layout e. g. "2006/01/02". If set, files are placed in
+	// the time partitioned subdirectories below Dir and all files with the Prefix in the whole
+	// tree below Dir are subject to deletion. Emptied subdirectories are removed.
+	// A new subdirectory is used from the next created file on.
+	// Use the time placeholders to partition templated files.
+	Partition string
+
+	// Header is optional and called for every new file. The returned bytes are written
+	// at the start of the file and count towards MaxBytes.
+	Header func() []byte
+
+	// Metrics is optional and receives the write, rotation and removal events of the writer.
+	Metrics Metrics
+
+	// Checksum is optional and writes the SHA-256 digest of every closed file into
+	// a sidecar file with the extension .sha256. See Verify.
+	Checksum ChecksumMode
+
+	// Encryption is optional and encrypts every file with its own data key, see OpenEncrypted.
+	// MaxBytes limits the encrypted file size.
+	Encryption *Encryption
+
+	// Limit is optional and limits the written bytes per second and the rotations per minute.
+	Limit *Limit
+
+	// PinFirst is optional and keeps the first file of every writer out of the rotation,
+	// e. g. to retain the startup logs. The file is marked as pinned by an empty .pin sidecar file.
+	PinFirst bool
+
+	// Pin is optional and keeps the files whose base name it matches out of the rotation.
+	Pin func(name string) bool
+
+	// MaxPinned is optional and limits the retained pinned files, defaults to MaxFiles.
+	MaxPinned int
+
+	// Dedup is optional and collapses consecutive identical records into one record and a summary.
+	Dedup *Dedup
+
+	// Redact is optional and redacts parts of every record before it is written.
+	// MaxBytes limits the redacted size.
+	Redact []Redaction
+
+	// Index is optional and keeps the files in memory instead of listing Dir on every rotation,
+	// which speeds up rotations in directories with many files. Files created or removed by others
+	// are noticed after IndexResync or on Resync, which the returned writer implements.
+	Index bool
+
+	// IndexResync is optional and lists Dir again on the first rotation after the duration, 0 never.
+	IndexResync time.Duration
+
+	// Preallocate is optional and preallocates every file to MaxBytes, Linux only. The file is written
+	// through a memory mapping and truncated to the written size on close. A size trailer allows
+	// to truncate files left preallocated after a crash, which New does for its files.
+	// On file systems without fallocate the files are written without preallocation.
+	Preallocate bool
+
+	// Staging is optional and appended to the name of the file while it is written, e. g. ".inprogress".
+	// The file is renamed to its final name when it is rotated or closed,
+	// so every file with the final name is complete.
+	Staging string
+
+	// Recover is optional and finalises and reports the files left by a previous run which did not
+	// close its file, e. g. after a crash.
+	Recover *Recovery
 }
 
 // DefaultConf returns a ready to use revolver conf.
@@ -51,25 +121,67 @@ func DefaultConf() Conf {
 	}
 }
 
-// ValidConf is deprecated. Use NewQuick instead.
+// ValidConf is deprecated. Use NewWriter instead.
 // ValidConf checks if the given conf is valid.
 // Calling revolver.New() will also validate the conf.
 func ValidConf(conf Conf) error {
 	switch {
 	case reflect.DeepEqual(conf, Conf{}):
-		return fmt.Errorf("revolver conf can not be empty")
-	case conf.Prefix == "":
-		return fmt.Errorf("revolver conf.Prefix can not be empty")
-	case conf.Middle == nil:
-		return fmt.Errorf("revolver conf.Middle can not be nil")
+		return invalidConf("", "can not be empty")
+	case conf.Prefix == "" && conf.Template == "":
+		return invalidConf("Prefix", "can not be empty")
+	case conf.Middle == nil && conf.Template == "":
+		return invalidConf("Middle", "can not be nil")
 	case conf.MaxFiles < 1:
-		return fmt.Errorf("revolver conf.MaxFiles must be > 0")
+		return invalidConf("MaxFiles", "must be > 0")
 	case conf.MaxBytes < 1:
-		return fmt.Errorf("revolver conf.MaxBytes must be > 0")
+		return invalidConf("MaxBytes", "must be > 0")
+	case conf.Encryption != nil && conf.Encryption.Keys == nil:
+		return invalidConf("Encryption.Keys", "can not be nil")
+	case conf.Preallocate && !canPreallocate:
+		return invalidConf("Preallocate", "is only supported on linux")
+	case validRedactions(conf.Redact) != nil:
+		return validRedactions(conf.Redact)
+	case strings.ContainsAny(conf.Staging, `/\`):
+		return invalidConf("Staging", "can not contain path separators")
+	case conf.IndexResync < 0:
+		return invalidConf("IndexResync", "must be >= 0")
+	case conf.Dedup != nil && conf.Dedup.Timeout < 0:
+		return invalidConf("Dedup.Timeout", "must be >= 0")
+	case conf.MaxPinned < 0:
+		return invalidConf("MaxPinned", "must be >= 0")
+	case conf.Limit != nil && validLimit(conf.Limit) != nil:
+		return validLimit(conf.Limit)
+	case conf.Template != "" && conf.Partition != "":
+		return invalidConf("Partition", "can not be combined with conf.Template")
+	case conf.Template != "":
+		if _, err := parseTemplate(conf.Template, conf.Values); err != nil {
+			return err
+		}
+	case conf.Partition != "":
+		return validPartition(conf.Partition)
+	}
+	return nil
+}
+
+func validPartition(partition string) error {
+	slashed := filepath.ToSlash(partition)
+	if strings.HasPrefix(slashed, "/") || strings.Contains("/"+slashed+"/", "/../") {
+		return invalidConf("Partition", "must be a relative path below Dir")
+	}
+	if !isTimeLayout(partition) {
+		return invalidConf("Partition", "'%s' is no time layout", partition)
 	}
 	return nil
 }
 
+// isTimeLayout reports whether the layout formats different times differently.
+func isTimeLayout(layout string) bool {
+	first := time.Date(2001, 2, 3, 4, 5, 6, 7e6, time.UTC)
+	second := time.Date(2012, 11, 22, 16, 15, 16, 17e6, time.UTC)
+	return first.Format(layout) != second.Format(layout)
+}
+
 func clean(conf Conf) Conf {
 	conf.Dir = filepath.Clean(conf.Dir)
 	return conf
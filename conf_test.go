@@ -39,6 +39,10 @@ func TestDefaultConfig(t *testing.T) {
 }
 
 func TestValidConf(t *testing.T) {
+	preallocateErr := ""
+	if !canPreallocate {
+		preallocateErr = "revolver conf.Preallocate is only supported on linux"
+	}
 	var tests = []struct {
 		conf Conf
 		err  string
@@ -85,6 +89,78 @@ func TestValidConf(t *testing.T) {
 			},
 			err: "",
 		},
+		{
+			conf: Conf{
+				Dir:      "log/",
+				Template: "{date}/{seq}.log",
+				MaxFiles: 1,
+				MaxBytes: 1,
+			},
+			err: "",
+		},
+		{
+			conf: Conf{
+				Dir:      "log/",
+				Template: "{unknown}.log",
+				MaxFiles: 1,
+				MaxBytes: 1,
+			},
+			err: "revolver conf.Template has unknown placeholder {unknown}",
+		},
+		{
+			conf: Conf{
+				Dir:       "log/",
+				Template:  "{seq}.log",
+				Partition: "2006/01/02",
+				MaxFiles:  1,
+				MaxBytes:  1,
+			},
+			err: "revolver conf.Partition can not be combined with conf.Template",
+		},
+		{
+			conf: Conf{
+				Dir:       "log/",
+				Prefix:    "log-",
+				Middle:    DateStringMiddle,
+				Partition: "2006/01/02",
+				MaxFiles:  1,
+				MaxBytes:  1,
+			},
+			err: "",
+		},
+		{
+			conf: Conf{
+				Dir:       "log/",
+				Prefix:    "log-",
+				Middle:    DateStringMiddle,
+				Partition: "../2006",
+				MaxFiles:  1,
+				MaxBytes:  1,
+			},
+			err: "revolver conf.Partition must be a relative path below Dir",
+		},
+		{
+			conf: Conf{
+				Dir:       "log/",
+				Prefix:    "log-",
+				Middle:    DateStringMiddle,
+				Partition: "archive",
+				MaxFiles:  1,
+				MaxBytes:  1,
+			},
+			err: "revolver conf.Partition 'archive' is no time layout",
+		},
+		{
+			conf: Conf{
+				Dir:         "log/",
+				Prefix:      "log-",
+				Middle:      DateStringMiddle,
+				MaxFiles:    1,
+				MaxBytes:    1,
+				Preallocate: true,
+			},
+			err: preallocateErr,
+		},
 	}
 
 	for index, test := range tests {
@@ -0,0 +1,104 @@
+package revolver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ContextWriter is implemented by the writers returned by New and NewBatch, e. g. to bound the
+// time spent on logging during a graceful shutdown.
+type ContextWriter interface {
+	io.WriteCloser
+	// WriteContext is like Write, but returns once ctx is done.
+	WriteContext(ctx context.Context, p []byte) (n int, err error)
+	// Shutdown is like Close, but returns once ctx is done.
+	Shutdown(ctx context.Context) error
+}
+
+// lockContext acquires the lock unless ctx is done before.
+func (l *Writer) lockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.lock.TryLock() {
+		return nil
+	}
+	locked := make(chan struct{})
+	go func() {
+		l.lock.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-locked
+			l.lock.Unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+type writeResult struct {
+	n   int
+	err error
+}
+
+// WriteContext writes p like Write. If ctx is done before the writer is free, nothing is written.
+// If ctx is done while the write or a rotation is in progress, the write is finished in the
+// background and an error is returned since it is not known whether p will be written.
+func (l *Writer) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	start := time.Now()
+	if err := l.lockContext(ctx); err != nil {
+		return 0, fmt.Errorf("revolver, write, %w, %d bytes not written", err, len(p))
+	}
+	if ctx.Done() == nil {
+		defer l.lock.Unlock()
+		return l.record(p, start)
+	}
+	record := append([]byte{}, p...) // p may be reused once WriteContext returns
+	done := make(chan writeResult, 1)
+	go func() {
+		defer l.lock.Unlock()
+		n, err := l.record(record, start)
+		done <- writeResult{n: n, err: err}
+	}()
+	select {
+	case result := <-done:
+		return result.n, result.err
+	case <-ctx.Done():
+		return 0, fmt.Errorf("revolver, write, %w, %d bytes still in progress", ctx.Err(), len(p))
+	}
+}
+
+// Shutdown closes the current file like Close. If ctx is done before the writer is free, the file
+// is not closed. If ctx is done while the file is closed, closing is finished in the background.
+func (l *Writer) Shutdown(ctx context.Context) error {
+	if err := l.lockContext(ctx); err != nil {
+		return fmt.Errorf("revolver, shutdown, %w, current file not closed", err)
+	}
+	l.stopped = ErrClosed
+	if l.file == nil {
+		l.lock.Unlock()
+		return nil
+	}
+	name := l.file.Name()
+	done := make(chan error, 1)
+	go func() {
+		defer l.lock.Unlock()
+		err := l.flushSummary()
+		if closeErr := l.close(); err == nil {
+			err = closeErr
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("revolver, shutdown, %w, closing '%s' still in progress", ctx.Err(), name)
+	}
+}
@@ -0,0 +1,103 @@
+package revolver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteContext(t *testing.T) {
+	var tests = []struct {
+		cancel  bool
+		locked  bool
+		rotate  bool
+		exp     string
+		content string
+	}{
+		{exp: "", content: "1234"},
+		{cancel: true, exp: "revolver, write, context canceled, 4 bytes not written", content: ""},
+		{locked: true, exp: "revolver, write, context deadline exceeded, 4 bytes not written", content: ""},
+		{rotate: true, exp: "revolver, write, context deadline exceeded, 4 bytes still in progress", content: "1234"},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. cancel: %t locked: %t rotate: %t", index, test.cancel, test.locked, test.rotate), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			seq := 0
+			rotating := make(chan struct{})
+			w, err := New(Conf{
+				Dir:    "test",
+				Prefix: "log_",
+				Middle: func() string {
+					if seq++; seq > 1 {
+						<-rotating
+					}
+					return strconv.Itoa(seq)
+				},
+				MaxFiles: 10,
+				MaxBytes: 8,
+			})
+			logErrAt(err, index, t)
+			rw := w.(*Writer)
+			if test.rotate {
+				_, err := w.Write([]byte("12345"))
+				logErrAt(err, index, t)
+			}
+			if test.locked {
+				rw.lock.Lock()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			if test.cancel {
+				cancel()
+			}
+			defer cancel()
+			if _, err := w.(ContextWriter).WriteContext(ctx, []byte("1234")); errStr(err) != test.exp {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.exp, err)
+			}
+			if test.locked {
+				rw.lock.Unlock()
+			}
+			close(rotating)
+			logErrAt(w.Close(), index, t)
+
+			data, err := ioutil.ReadFile(filepath.Join("test", "log_"+strconv.Itoa(seq)))
+			logErrAt(err, index, t)
+			if string(data) != test.content {
+				t.Errorf("%d. exp content: %q got: %q", index, test.content, data)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := New(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 10, MaxBytes: 8, Checksum: ChecksumFile})
+	logErr(err, t)
+	rw := w.(*Writer)
+	name := rw.file.Name()
+
+	rw.lock.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	exp := "revolver, shutdown, context deadline exceeded, current file not closed"
+	if err := w.(ContextWriter).Shutdown(ctx); errStr(err) != exp {
+		t.Errorf("exp err: '%s' got: '%v'", exp, err)
+	}
+	rw.lock.Unlock()
+	if _, err := os.Stat(name + checksumExt); !os.IsNotExist(err) {
+		t.Errorf("exp '%s' not to be closed got: %v", name, err)
+	}
+
+	logErr(w.(ContextWriter).Shutdown(context.Background()), t)
+	logErr(Verify("test", "log_"), t)
+	logErr(w.(ContextWriter).Shutdown(context.Background()), t)
+}
@@ -0,0 +1,117 @@
+package revolver
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Dedup collapses consecutive identical records into the first record and a
+// "last message repeated N times" summary. The summary is written when a different record arrives,
+// before the file is rotated or closed or after Timeout without a further record.
+// On Close a summary which doesn't fit is written past MaxBytes, with Preallocate it is dropped.
+type Dedup struct {
+	Timeout time.Duration // optional, 0 writes the summary only on a different record, rotation or close
+}
+
+type deduper struct {
+	last     []byte
+	repeated int
+	timeout  time.Duration
+	timer    *time.Timer
+	expired  func()
+}
+
+func newDeduper(dedup *Dedup, expired func()) *deduper {
+	if dedup == nil {
+		return nil
+	}
+	return &deduper{timeout: dedup.Timeout, expired: expired}
+}
+
+// repeat reports whether p repeats the last record and counts it, else p becomes the last record.
+func (d *deduper) repeat(p []byte) bool {
+	if d.last != nil && bytes.Equal(p, d.last) {
+		d.repeated++
+		if d.timeout > 0 {
+			if d.timer == nil {
+				d.timer = time.AfterFunc(d.timeout, d.expired)
+			} else {
+				d.timer.Reset(d.timeout)
+			}
+		}
+		return true
+	}
+	d.last = append(d.last[:0], p...)
+	return false
+}
+
+// summary returns the pending summary or nil.
+func (d *deduper) summary() []byte {
+	if d.repeated == 0 {
+		return nil
+	}
+	return []byte(fmt.Sprintf("last message repeated %d times\n", d.repeated))
+}
+
+// writeSummary writes the pending summary into the current file if it fits,
+// else it stays pending until the next file is opened.
+func (l *Writer) writeSummary() error {
+	if l.dedup == nil || l.file == nil {
+		return nil
+	}
+	summary := l.dedup.summary()
+	if summary == nil {
+		return nil
+	}
+	size := l.sizeOf(len(summary))
+	if l.size+size > l.maxBytes {
+		return nil
+	}
+	l.size += size
+	if _, err := l.out().Write(summary); err != nil {
+		return fmt.Errorf("revolver, dedup, %w", err)
+	}
+	l.dedup.repeated = 0
+	return nil
+}
+
+// flushSummary stops the dedup timer and writes the pending summary before the writer stops.
+// A summary which doesn't fit is written past the max size, for a mapped file it is dropped and
+// counted as failed write.
+func (l *Writer) flushSummary() error {
+	if l.dedup == nil {
+		return nil
+	}
+	if l.dedup.timer != nil {
+		l.dedup.timer.Stop()
+	}
+	if err := l.writeSummary(); err != nil {
+		return err
+	}
+	summary := l.dedup.summary()
+	if summary == nil || l.file == nil {
+		return nil
+	}
+	if l.mapping != nil {
+		// the mapping can't grow and a closed writer creates no new file
+		l.metrics.WriteFailed()
+		l.dedup.repeated = 0
+		return nil
+	}
+	l.size += l.sizeOf(len(summary))
+	if _, err := l.out().Write(summary); err != nil {
+		return fmt.Errorf("revolver, dedup, %w", err)
+	}
+	l.dedup.repeated = 0
+	return nil
+}
+
+// expiredSummary writes the pending summary after the dedup timeout.
+func (l *Writer) expiredSummary() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.writeSummary(); err != nil {
+		l.metrics.WriteFailed()
+	}
+}
@@ -0,0 +1,95 @@
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	var tests = []struct {
+		writes   []string
+		maxBytes int
+		exp      string
+	}{
+		{writes: []string{"a\n", "a\n", "a\n", "b\n"}, maxBytes: 100, exp: "a\nlast message repeated 2 times\nb\n"},
+		{writes: []string{"a\n", "b\n", "a\n"}, maxBytes: 100, exp: "a\nb\na\n"},
+		{writes: []string{"a\n", "a\n"}, maxBytes: 100, exp: "a\nlast message repeated 1 times\n"},
+		{
+			writes:   []string{"a\n", "a\n", "a\n", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\n"},
+			maxBytes: 32,
+			exp:      "a\nlast message repeated 2 times\nbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\n",
+		},
+		{
+			writes:   []string{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\n", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\n", "b\n"},
+			maxBytes: 32,
+			exp:      "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\nlast message repeated 1 times\nb\n",
+		},
+		{
+			writes:   []string{"1234567\n", "1234567\n", "1234567\n", "1234567\n", "1234567\n"},
+			maxBytes: 8,
+			exp:      "1234567\nlast message repeated 4 times\n",
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. writes: %q", index, test.writes), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			w, err := New(Conf{
+				Dir:      "test",
+				Prefix:   "log_",
+				Middle:   testCounterMiddle(),
+				MaxFiles: 10,
+				MaxBytes: test.maxBytes,
+				Dedup:    &Dedup{},
+			})
+			logErrAt(err, index, t)
+			for _, write := range test.writes {
+				n, err := w.Write([]byte(write))
+				logErrAt(err, index, t)
+				if n != len(write) {
+					t.Errorf("%d. exp n: %d got: %d", index, len(write), n)
+				}
+			}
+			logErrAt(w.Close(), index, t)
+			if got := readAll(t); got != test.exp {
+				t.Errorf("%d. exp content: %q got: %q", index, test.exp, got)
+			}
+		})
+	}
+}
+
+func TestDedupTimeout(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := New(Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   testMiddlePartFunc,
+		MaxFiles: 1,
+		MaxBytes: 100,
+		Dedup:    &Dedup{Timeout: 20 * time.Millisecond},
+	})
+	logErr(err, t)
+	for write := 0; write < 4; write++ {
+		_, err := w.Write([]byte("retry\n"))
+		logErr(err, t)
+	}
+	exp := "retry\nlast message repeated 3 times\n"
+	deadline := time.Now().Add(time.Second)
+	for readAll(t) != exp && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := readAll(t); got != exp {
+		t.Errorf("exp summary after timeout: %q got: %q", exp, got)
+	}
+	_, err = w.Write([]byte("retry\n"))
+	logErr(err, t)
+	logErr(w.Close(), t)
+	if got, exp := readAll(t), exp+"last message repeated 1 times\n"; got != exp {
+		t.Errorf("exp summary on close: %q got: %q", exp, got)
+	}
+}
@@ -0,0 +1,334 @@
+package revolver
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// KeyProvider provides the per file data keys of encrypted files.
+type KeyProvider interface {
+	// NewKey returns a new data key and its wrapped form, e. g. encrypted by a KMS.
+	// The wrapped form is stored in the file header.
+	NewKey() (key, wrapped []byte, err error)
+	// Key returns the data key of the wrapped form read from a file header.
+	Key(wrapped []byte) ([]byte, error)
+}
+
+// Encryption specifies the at rest encryption of the written files.
+// Every file is encrypted with its own data key as a stream of AEAD sealed chunks.
+type Encryption struct {
+	Keys KeyProvider
+	// AEAD is optional and returns the cipher for a data key. Defaults to AES-GCM,
+	// chacha20poly1305.New from golang.org/x/crypto can be used as well.
+	AEAD func(key []byte) (cipher.AEAD, error)
+	// ChunkSize is optional and limits the plaintext bytes per chunk, defaults to 64 KiB.
+	// Every write is sealed into at least one chunk, so no written bytes are buffered.
+	// The chunk size is stored in the file header, so files are read with any chunk size.
+	ChunkSize int
+}
+
+const (
+	defaultChunkSize = 64 * 1024
+	chunkLenSize     = 4
+	nonceSuffixSize  = 5 // chunk counter and final flag
+)
+
+var encryptionMagic = []byte("RVENC2")
+
+func (e *Encryption) aead(key []byte) (cipher.AEAD, error) {
+	if e.AEAD != nil {
+		return e.AEAD(key)
+	}
+	return newAESGCM(key)
+}
+
+func (e *Encryption) chunkSize() int {
+	if e.ChunkSize > 0 {
+		return e.ChunkSize
+	}
+	return defaultChunkSize
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealer encrypts the written bytes into a chunked AEAD stream.
+// The nonce of every chunk is the random file nonce prefix, the chunk counter and the final flag.
+type sealer struct {
+	out    io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	count  uint32
+	chunk  int
+}
+
+// newSealer writes the encryption header into out and returns the sealer and the header size.
+func newSealer(out io.Writer, enc *Encryption) (*sealer, int, error) {
+	key, wrapped, err := enc.Keys.NewKey()
+	if err != nil {
+		return nil, 0, fmt.Errorf("error on data key, %w", err)
+	}
+	aead, err := enc.aead(key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error on cipher, %w", err)
+	}
+	if aead.NonceSize() <= nonceSuffixSize || len(wrapped) > 0xffff {
+		return nil, 0, fmt.Errorf("error on cipher, unsupported nonce or wrapped key size")
+	}
+	if !validChunkSize(uint64(enc.chunkSize()), aead) {
+		return nil, 0, fmt.Errorf("error on cipher, unsupported chunk size %d", enc.chunkSize())
+	}
+	prefix := make([]byte, aead.NonceSize()-nonceSuffixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, 0, fmt.Errorf("error on nonce, %w", err)
+	}
+
+	header := &bytes.Buffer{}
+	header.Write(encryptionMagic)
+	binary.Write(header, binary.BigEndian, uint32(enc.chunkSize()))
+	binary.Write(header, binary.BigEndian, uint16(len(wrapped)))
+	header.Write(wrapped)
+	header.Write(prefix)
+	n, err := out.Write(header.Bytes())
+	if err != nil {
+		return nil, n, fmt.Errorf("error on encryption header, %w", err)
+	}
+	return &sealer{out: out, aead: aead, prefix: prefix, chunk: enc.chunkSize()}, n, nil
+}
+
+// validChunkSize reports whether the sealed chunks of the size have a uint32 length.
+func validChunkSize(size uint64, aead cipher.AEAD) bool {
+	return size > 0 && size+uint64(aead.Overhead()) <= math.MaxUint32
+}
+
+func (s *sealer) nonce(final bool) []byte {
+	nonce := make([]byte, 0, s.aead.NonceSize())
+	nonce = append(nonce, s.prefix...)
+	nonce = append(nonce, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(nonce[len(s.prefix):], s.count)
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// size returns the bytes written to out when p bytes are written.
+func (s *sealer) size(p int) int {
+	chunks := (p + s.chunk - 1) / s.chunk
+	return p + chunks*s.finalSize()
+}
+
+// finalSize returns the size of the empty final chunk, which is the overhead of every chunk.
+func (s *sealer) finalSize() int {
+	return chunkLenSize + s.aead.Overhead()
+}
+
+// Write seals p into chunks of at most the chunk size and returns the plaintext bytes written.
+func (s *sealer) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		part := p
+		if len(part) > s.chunk {
+			part = part[:s.chunk]
+		}
+		if err := s.seal(part, false); err != nil {
+			return n, err
+		}
+		n += len(part)
+		p = p[len(part):]
+	}
+	return n, nil
+}
+
+// finish writes the empty final chunk which marks the stream as complete.
+func (s *sealer) finish() error {
+	return s.seal(nil, true)
+}
+
+func (s *sealer) seal(p []byte, final bool) error {
+	if s.count == ^uint32(0) {
+		return fmt.Errorf("error on encryption, too many chunks")
+	}
+	chunk := make([]byte, chunkLenSize, chunkLenSize+len(p)+s.aead.Overhead())
+	chunk = s.aead.Seal(chunk, s.nonce(final), p, nil)
+	binary.BigEndian.PutUint32(chunk, uint32(len(chunk)-chunkLenSize))
+	s.count++
+	_, err := s.out.Write(chunk)
+	return err
+}
+
+// Decrypter reads the plaintext of a file encrypted by revolver.
+type Decrypter struct {
+	in       io.Reader
+	aead     cipher.AEAD
+	prefix   []byte
+	maxChunk uint32
+	count    uint32
+	buf      []byte
+	final    bool
+	err      error // returned after the final chunk
+}
+
+// NewDecrypter reads the encryption header from in and returns a Decrypter for the remaining stream.
+// If the stream ends without its final chunk, e. g. after a crash or truncation,
+// Read returns io.ErrUnexpectedEOF after all complete chunks. Data appended after the final chunk
+// is returned as an error instead of io.EOF.
+func NewDecrypter(in io.Reader, enc *Encryption) (*Decrypter, error) {
+	magic := make([]byte, len(encryptionMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || !bytes.Equal(magic, encryptionMagic) {
+		return nil, fmt.Errorf("revolver, decrypt, no revolver encryption header")
+	}
+	var chunkSize uint32
+	if err := binary.Read(in, binary.BigEndian, &chunkSize); err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, %w", err)
+	}
+	var length uint16
+	if err := binary.Read(in, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, %w", err)
+	}
+	wrapped := make([]byte, length)
+	if _, err := io.ReadFull(in, wrapped); err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, %w", err)
+	}
+	key, err := enc.Keys.Key(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, data key, %w", err)
+	}
+	aead, err := enc.aead(key)
+	if err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, cipher, %w", err)
+	}
+	if aead.NonceSize() <= nonceSuffixSize {
+		return nil, fmt.Errorf("revolver, decrypt, unsupported nonce size")
+	}
+	prefix := make([]byte, aead.NonceSize()-nonceSuffixSize)
+	if _, err := io.ReadFull(in, prefix); err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, %w", err)
+	}
+	if !validChunkSize(uint64(chunkSize), aead) {
+		return nil, fmt.Errorf("revolver, decrypt, unsupported chunk size %d", chunkSize)
+	}
+	maxChunk := chunkSize + uint32(aead.Overhead())
+	return &Decrypter{in: in, aead: aead, prefix: prefix, maxChunk: maxChunk}, nil
+}
+
+// OpenEncrypted opens the given encrypted file for reading its plaintext.
+func OpenEncrypted(name string, enc *Encryption) (io.ReadCloser, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("revolver, decrypt, %w", err)
+	}
+	dec, err := NewDecrypter(file, enc)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{dec, file}, nil
+}
+
+// Read reads the decrypted plaintext.
+func (d *Decrypter) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.final {
+			if d.err == nil {
+				d.err = d.end()
+			}
+			return 0, d.err
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *Decrypter) open() error {
+	var length uint32
+	if err := binary.Read(d.in, binary.BigEndian, &length); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF // final chunk missing
+		}
+		return err
+	}
+	if length > d.maxChunk {
+		return fmt.Errorf("revolver, decrypt, chunk %d length %d over max %d", d.count, length, d.maxChunk)
+	}
+	chunk := make([]byte, length)
+	if _, err := io.ReadFull(d.in, chunk); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	s := sealer{aead: d.aead, prefix: d.prefix, count: d.count}
+	plain, err := d.aead.Open(nil, s.nonce(false), chunk, nil)
+	if err != nil {
+		plain, err = d.aead.Open(nil, s.nonce(true), chunk, nil)
+		if err != nil {
+			return fmt.Errorf("revolver, decrypt, chunk %d is not authentic", d.count)
+		}
+		d.final = true
+	}
+	d.count++
+	d.buf = plain
+	return nil
+}
+
+// end returns io.EOF if the stream ends after the final chunk.
+func (d *Decrypter) end() error {
+	_, err := io.ReadFull(d.in, make([]byte, 1))
+	switch err {
+	case nil:
+		return fmt.Errorf("revolver, decrypt, data after the final chunk %d", d.count-1)
+	case io.EOF:
+		return io.EOF
+	}
+	return err
+}
+
+type staticKeys struct {
+	aead cipher.AEAD
+}
+
+// NewStaticKeys returns a KeyProvider which creates random 32 byte data keys
+// wrapped with AES-GCM under the given 16, 24 or 32 byte master key.
+func NewStaticKeys(master []byte) (KeyProvider, error) {
+	aead, err := newAESGCM(master)
+	if err != nil {
+		return nil, fmt.Errorf("revolver, master key, %w", err)
+	}
+	return &staticKeys{aead: aead}, nil
+}
+
+func (s *staticKeys) NewKey() (key, wrapped []byte, err error) {
+	key = make([]byte, 32)
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return key, s.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (s *staticKeys) Key(wrapped []byte) ([]byte, error) {
+	size := s.aead.NonceSize()
+	if len(wrapped) < size {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	return s.aead.Open(nil, wrapped[:size], wrapped[size:], nil)
+}
@@ -0,0 +1,232 @@
+package revolver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testEncryption(t *testing.T, chunkSize int) *Encryption {
+	keys, err := NewStaticKeys(bytes.Repeat([]byte{7}, 32))
+	logErr(err, t)
+	return &Encryption{Keys: keys, ChunkSize: chunkSize}
+}
+
+func TestEncryptedWriter(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	enc := testEncryption(t, 4)
+	conf := Conf{
+		Dir:        "test",
+		Template:   "log-{seq}.enc",
+		MaxFiles:   10,
+		MaxBytes:   256,
+		Header:     func() []byte { return []byte("header\n") },
+		Encryption: enc,
+	}
+	w, err := New(conf)
+	logErr(err, t)
+	var exp []string
+	for line := 0; line < 6; line++ {
+		record := fmt.Sprintf("secret line %d\n", line)
+		_, err := w.Write([]byte(record))
+		logErr(err, t)
+		exp = append(exp, record)
+	}
+	logErr(w.Close(), t)
+
+	files, err := ioutil.ReadDir("test")
+	logErr(err, t)
+	if len(files) < 2 {
+		t.Fatalf("exp encrypted files to rotate got: %d files", len(files))
+	}
+	plain := &bytes.Buffer{}
+	for _, info := range files {
+		if info.Size() > int64(conf.MaxBytes) {
+			t.Errorf("exp '%s' size <= %d got: %d", info.Name(), conf.MaxBytes, info.Size())
+		}
+		name := filepath.Join("test", info.Name())
+		raw, err := ioutil.ReadFile(name)
+		logErr(err, t)
+		if bytes.Contains(raw, []byte("secret")) {
+			t.Errorf("exp '%s' to be encrypted", info.Name())
+		}
+		r, err := OpenEncrypted(name, enc)
+		logErr(err, t)
+		data, err := ioutil.ReadAll(r)
+		logErr(err, t)
+		logErr(r.Close(), t)
+		if !strings.HasPrefix(string(data), "header\n") {
+			t.Errorf("exp '%s' to start with header got: '%s'", info.Name(), data)
+		}
+		plain.WriteString(strings.TrimPrefix(string(data), "header\n"))
+	}
+	if got := plain.String(); got != strings.Join(exp, "") {
+		t.Errorf("exp plaintext: '%s' got: '%s'", strings.Join(exp, ""), got)
+	}
+}
+
+// failingKeys fails to create the next fail data keys.
+type failingKeys struct {
+	KeyProvider
+	fail int
+}
+
+func (f *failingKeys) NewKey() (key, wrapped []byte, err error) {
+	if f.fail > 0 {
+		f.fail--
+		return nil, nil, fmt.Errorf("key provider unavailable")
+	}
+	return f.KeyProvider.NewKey()
+}
+
+func TestEncryptedWriterKeyErr(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	enc := testEncryption(t, 16)
+	keys := &failingKeys{KeyProvider: enc.Keys}
+	enc.Keys = keys
+	w, err := New(Conf{Dir: "test", Prefix: "log_", Middle: testCounterMiddle(), MaxFiles: 10, MaxBytes: 256, Encryption: enc})
+	logErr(err, t)
+	_, err = w.Write([]byte("first\n"))
+	logErr(err, t)
+	rev := w.(*Writer)
+	full := 1 // fills an empty file, so it is written to the next file
+	for rev.sizeOf(full+1) <= rev.maxBytes-rev.base {
+		full++
+	}
+	keys.fail = 1
+	exp := "revolver, encryption, error on data key, key provider unavailable"
+	if _, err := w.Write(bytes.Repeat([]byte("x"), full)); errStr(err) != exp {
+		t.Errorf("exp err: '%s' got: '%v'", exp, err)
+	}
+	_, err = w.Write([]byte("written\n"))
+	logErr(err, t)
+	logErr(w.Close(), t)
+
+	if got := fmt.Sprint(dirNames(t)); got != "[log_1 log_3]" {
+		t.Errorf("exp the failed file to be removed got: %s", got)
+	}
+	r, err := OpenEncrypted(filepath.Join("test", "log_3"), enc)
+	logErr(err, t)
+	data, err := ioutil.ReadAll(r)
+	logErr(err, t)
+	logErr(r.Close(), t)
+	if string(data) != "written\n" {
+		t.Errorf("exp plaintext: 'written\n' got: '%s'", data)
+	}
+}
+
+func TestDecrypter(t *testing.T) {
+	enc := testEncryption(t, 3)
+	encrypted := &bytes.Buffer{}
+	s, headerSize, err := newSealer(encrypted, enc)
+	logErr(err, t)
+	_, err = s.Write([]byte("0123456789"))
+	logErr(err, t)
+	complete := append([]byte{}, encrypted.Bytes()...)
+	logErr(s.finish(), t)
+	final := encrypted.Bytes()
+
+	otherKeys, err := NewStaticKeys(bytes.Repeat([]byte{8}, 32))
+	logErr(err, t)
+	tampered := append([]byte{}, final...)
+	tampered[len(tampered)-10] ^= 1
+	oversized := append([]byte{}, final...)
+	oversized[headerSize] = 0xff // length of the first chunk
+	appended := append(append([]byte{}, final...), "appended"...)
+
+	var tests = []struct {
+		data []byte
+		enc  *Encryption
+		exp  string
+		err  string
+	}{
+		{data: final, enc: enc, exp: "0123456789"},
+		{data: complete, enc: enc, exp: "0123456789", err: io.ErrUnexpectedEOF.Error()},
+		{data: final[:len(final)-3], enc: enc, exp: "0123456789", err: io.ErrUnexpectedEOF.Error()},
+		{data: tampered, enc: enc, exp: "0123456789", err: "revolver, decrypt, chunk 4 is not authentic"},
+		{data: oversized, enc: enc, exp: "", err: "revolver, decrypt, chunk 0 length 4278190099 over max 19"},
+		{data: appended, enc: enc, exp: "0123456789", err: "revolver, decrypt, data after the final chunk 4"},
+		{data: final, enc: &Encryption{Keys: otherKeys}, err: "revolver, decrypt, data key,"},
+		{data: []byte("plain text"), enc: enc, err: "revolver, decrypt, no revolver encryption header"},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. decrypt err: %s", index, test.err), func(t *testing.T) {
+			t.Parallel()
+			dec, err := NewDecrypter(bytes.NewReader(test.data), test.enc)
+			got := []byte{}
+			if err == nil {
+				got, err = ioutil.ReadAll(dec)
+			}
+			if !strings.HasPrefix(errStr(err), test.err) {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			if string(got) != test.exp {
+				t.Errorf("%d. exp plaintext: '%s' got: '%s'", index, test.exp, got)
+			}
+		})
+	}
+}
+
+func TestDecrypterChunkSize(t *testing.T) {
+	var tests = []struct {
+		write int
+		read  int
+	}{
+		{write: 128 * 1024, read: 0},
+		{write: 0, read: 128 * 1024},
+		{write: 3, read: 64},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. write chunk: %d read chunk: %d", index, test.write, test.read), func(t *testing.T) {
+			enc := testEncryption(t, test.write)
+			encrypted := &bytes.Buffer{}
+			s, _, err := newSealer(encrypted, enc)
+			logErrAt(err, index, t)
+			plain := bytes.Repeat([]byte("0123456789"), 20*1024)
+			_, err = s.Write(plain)
+			logErrAt(err, index, t)
+			logErrAt(s.finish(), index, t)
+
+			dec, err := NewDecrypter(encrypted, &Encryption{Keys: enc.Keys, ChunkSize: test.read})
+			logErrAt(err, index, t)
+			got, err := ioutil.ReadAll(dec)
+			logErrAt(err, index, t)
+			if !bytes.Equal(got, plain) {
+				t.Errorf("%d. exp %d plaintext bytes got: %d", index, len(plain), len(got))
+			}
+		})
+	}
+}
+
+func TestSealerSize(t *testing.T) {
+	enc := testEncryption(t, 4)
+	out := &bytes.Buffer{}
+	s, header, err := newSealer(out, enc)
+	logErr(err, t)
+	for _, size := range []int{0, 1, 4, 5, 9} {
+		before := out.Len()
+		_, err := s.Write(make([]byte, size))
+		logErr(err, t)
+		if got := out.Len() - before; got != s.size(size) {
+			t.Errorf("exp size of %d bytes: %d got: %d", size, s.size(size), got)
+		}
+	}
+	before := out.Len()
+	logErr(s.finish(), t)
+	if got := out.Len() - before; got != s.finalSize() {
+		t.Errorf("exp final size: %d got: %d", s.finalSize(), got)
+	}
+	if header <= len(encryptionMagic) {
+		t.Errorf("exp header size > %d got: %d", len(encryptionMagic), header)
+	}
+}
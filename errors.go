@@ -0,0 +1,57 @@
+package revolver
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClosed is returned by writes after Close.
+	ErrClosed = errors.New("revolver, writer closed")
+	// ErrReleased is returned by writes after Release until Reopen.
+	ErrReleased = errors.New("revolver, writer released")
+	// ErrWriteTooLarge is returned by writes which don't fit into an empty file.
+	ErrWriteTooLarge = errors.New("revolver, write too large")
+)
+
+// ErrInvalidConf is wrapped by every ConfError.
+var ErrInvalidConf = errors.New("revolver, invalid conf")
+
+// ConfError is returned for an invalid conf field, errors.Is(err, ErrInvalidConf) reports true for it.
+type ConfError struct {
+	Field  string // e. g. MaxBytes or Limit.Burst, empty if the whole conf is invalid
+	Reason string
+}
+
+func invalidConf(field, format string, args ...interface{}) error {
+	return &ConfError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (e *ConfError) Error() string {
+	if e.Field == "" {
+		return "revolver conf " + e.Reason
+	}
+	return "revolver conf." + e.Field + " " + e.Reason
+}
+
+// Unwrap returns ErrInvalidConf.
+func (e *ConfError) Unwrap() error {
+	return ErrInvalidConf
+}
+
+// RotateError is returned if a file operation of a write, rotation or close fails.
+// It wraps the underlying error, e. g. an *os.PathError.
+type RotateError struct {
+	Op   string // remove, create, preallocate, encryption, header or close
+	Path string // the file or for remove and create the dir
+	Err  error
+}
+
+func (e *RotateError) Error() string {
+	return "revolver, " + e.Op + ", " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *RotateError) Unwrap() error {
+	return e.Err
+}
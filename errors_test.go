@@ -0,0 +1,58 @@
+package revolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	defer func() {
+		logErr(os.Chmod("test", 0755), t)
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := New(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 10, MaxBytes: 4})
+	logErr(err, t)
+	_, tooLarge := w.Write([]byte("12345"))
+	_, canceled := w.(ContextWriter).WriteContext(canceledContext(), []byte("1"))
+	_, err = w.Write([]byte("1234"))
+	logErr(err, t)
+	logErr(os.Chmod("test", 0555), t)
+	_, rotate := w.Write([]byte("5678"))
+	logErr(os.Chmod("test", 0755), t)
+	logErr(w.Close(), t)
+	_, closed := w.Write([]byte("1"))
+	invalid := ValidConf(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1})
+
+	var conf *ConfError
+	var rotateErr *RotateError
+	var tests = []struct {
+		name string
+		err  error
+		ok   bool
+	}{
+		{name: "invalid conf", err: invalid, ok: errors.Is(invalid, ErrInvalidConf)},
+		{name: "conf field", err: invalid, ok: errors.As(invalid, &conf) && conf.Field == "MaxBytes"},
+		{name: "too large", err: tooLarge, ok: errors.Is(tooLarge, ErrWriteTooLarge)},
+		{name: "canceled", err: canceled, ok: errors.Is(canceled, context.Canceled)},
+		{name: "rotate", err: rotate, ok: errors.As(rotate, &rotateErr) && rotateErr.Op == "create" && rotateErr.Path == "test"},
+		{name: "permission", err: rotate, ok: errors.Is(rotate, fs.ErrPermission)},
+		{name: "closed", err: closed, ok: errors.Is(closed, ErrClosed)},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. %s", index, test.name), func(t *testing.T) {
+			if test.err == nil || !test.ok {
+				t.Errorf("%d. exp %s err got: %v", index, test.name, test.err)
+			}
+		})
+	}
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
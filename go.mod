@@ -1 +1,3 @@
 module github.com/jksch/revolver
+
+go 1.21
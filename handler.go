@@ -0,0 +1,54 @@
+package revolver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// HeaderMessage is the message of the header record written at the start of every file by NewHandler.
+const HeaderMessage = "revolver file header"
+
+// HandlerOptions are the options for NewHandler.
+type HandlerOptions struct {
+	slog.HandlerOptions
+
+	// Header attributes e. g. service, host and build are written as first record into every file.
+	Header []slog.Attr
+	// Text selects slog.TextHandler instead of slog.JSONHandler.
+	Text bool
+}
+
+// NewHandler returns a slog.Handler writing into revolving files as specified by the given conf
+// and the closer to flush and close the current file.
+// slog handlers write every record with a single write, so records are never split across files.
+// Records larger than conf.MaxBytes are not written, the handler returns the write error.
+// If opts is nil a JSON handler without header is returned. Any conf.Header is replaced by the header record.
+func NewHandler(conf Conf, opts *HandlerOptions) (slog.Handler, io.Closer, error) {
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+	if len(opts.Header) > 0 {
+		conf.Header = func() []byte {
+			buf := &bytes.Buffer{}
+			record := slog.NewRecord(time.Now(), slog.LevelInfo, HeaderMessage, 0)
+			record.AddAttrs(opts.Header...)
+			_ = newSlogHandler(buf, opts).Handle(context.Background(), record)
+			return buf.Bytes()
+		}
+	}
+	w, err := New(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newSlogHandler(w, opts), w, nil
+}
+
+func newSlogHandler(w io.Writer, opts *HandlerOptions) slog.Handler {
+	if opts.Text {
+		return slog.NewTextHandler(w, &opts.HandlerOptions)
+	}
+	return slog.NewJSONHandler(w, &opts.HandlerOptions)
+}
@@ -0,0 +1,76 @@
+package revolver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewHandler(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	conf := Conf{Dir: "test", Template: "log-{seq}.json", MaxFiles: 10, MaxBytes: 250}
+	handler, closer, err := NewHandler(conf, &HandlerOptions{
+		Header: []slog.Attr{slog.String("service", "api"), slog.String("build", "1.2.3")},
+	})
+	logErr(err, t)
+	logger := slog.New(handler)
+	for record := 0; record < 5; record++ {
+		logger.Info("record", "number", record)
+	}
+	logErr(closer.Close(), t)
+
+	files, err := ioutil.ReadDir("test")
+	logErr(err, t)
+	if len(files) < 2 {
+		t.Fatalf("exp records to rotate into several files got: %d", len(files))
+	}
+	records := 0
+	for _, info := range files {
+		data, err := ioutil.ReadFile(filepath.Join("test", info.Name()))
+		logErr(err, t)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		for index, line := range lines {
+			entry := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("exp complete json record in '%s' got: '%s', %v", info.Name(), line, err)
+			}
+			if index == 0 {
+				if entry["msg"] != HeaderMessage || entry["service"] != "api" || entry["build"] != "1.2.3" {
+					t.Errorf("exp header record in '%s' got: '%s'", info.Name(), line)
+				}
+				continue
+			}
+			records++
+		}
+	}
+	if records != 5 {
+		t.Errorf("exp 5 records got: %d", records)
+	}
+}
+
+func TestNewHandlerText(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	conf := Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 1024}
+	handler, closer, err := NewHandler(conf, &HandlerOptions{Text: true})
+	logErr(err, t)
+	slog.New(handler).Warn("text")
+	logErr(closer.Close(), t)
+
+	data, err := ioutil.ReadFile(filepath.FromSlash("test/log_" + testMiddlePart))
+	logErr(err, t)
+	if !strings.Contains(string(data), "level=WARN msg=text") || strings.Contains(string(data), HeaderMessage) {
+		t.Errorf("exp text record without header got: '%s'", data)
+	}
+
+	if _, _, err := NewHandler(Conf{}, nil); errStr(err) != "revolver conf can not be empty" {
+		t.Errorf("exp conf err got: '%v'", err)
+	}
+}
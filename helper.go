@@ -5,8 +5,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func setupDirs(dirs string) error {
@@ -22,65 +24,144 @@ func setupDirs(dirs string) error {
 		return nil
 	}
 	if !os.IsNotExist(err) {
-		return fmt.Errorf("error in dir setup, %v", err)
+		return fmt.Errorf("error in dir setup, %w", err)
 	}
 	return os.MkdirAll(dirs, 0755)
 }
 
 func createFile(dir, prefix, suffix string, filename func() string) (*os.File, error) {
+	return createStagedFile(dir, prefix, suffix, "", filename)
+}
+
+// createStagedFile creates the file with the staging suffix appended to its name.
+// The name is only used if neither the final nor the staged file exists.
+func createStagedFile(dir, prefix, suffix, staging string, filename func() string) (*os.File, error) {
 	name := filepath.FromSlash(filepath.Join(dir, prefix+filename()))
 	try := 0
 	file := name
 	for {
 		file = file + suffix
-		if _, err := os.Stat(file); err != nil {
-			if os.IsNotExist(err) {
-				return os.Create(file)
-			}
-			return nil, fmt.Errorf("error on create file, %v", err)
+		exists, err := anyExists(file, file+staging)
+		if err != nil {
+			return nil, fmt.Errorf("error on create file, %w", err)
+		}
+		if !exists {
+			return os.Create(file + staging)
 		}
 		file = name + "_" + strconv.Itoa(try)
 		try++
 	}
 }
 
-func fileCount(dir, prefix string) (int, error) {
-	files, err := ioutil.ReadDir(filepath.FromSlash(dir))
-	if err != nil {
-		return 0, fmt.Errorf("error while counting files, %v", err)
+func anyExists(names ...string) (bool, error) {
+	for _, name := range names {
+		if _, err := os.Stat(name); err == nil {
+			return true, nil
+		} else if !os.IsNotExist(err) {
+			return false, err
+		}
 	}
+	return false, nil
+}
+
+// naming creates the files of a writer and lists the files it created before.
+type naming interface {
+	// create creates the next file below dir.
+	create(dir string) (*os.File, error)
+	// files returns the paths of all files below dir which belong to the naming, oldest first.
+	files(dir string) ([]string, error)
+}
+
+// prefixNaming names files prefix + middle + suffix and owns all files in dir with the prefix.
+// With a partition the files are placed in time partitioned subdirectories and
+// all files with the prefix in the whole tree below dir are owned.
+type prefixNaming struct {
+	prefix    string
+	suffix    string
+	middle    func() string
+	partition string // optional time layout of the subdirectories
+	staging   string // optional suffix of the file while it is written
+}
 
-	count := 0
-	for _, info := range files {
-		if isRevolverFile(prefix, info) {
-			count++
+func (n prefixNaming) create(dir string) (*os.File, error) {
+	if n.partition != "" {
+		dir = filepath.Join(dir, partitionDir(n.partition, time.Now().UTC()))
+		if err := setupDirs(dir); err != nil {
+			return nil, fmt.Errorf("error on create file, %w", err)
 		}
 	}
-	return count, nil
+	return createStagedFile(dir, n.prefix, n.suffix, n.staging, n.middle)
 }
 
-func removeOldestFile(dir, prefix string) error {
+func (n prefixNaming) files(dir string) ([]string, error) {
 	dir = filepath.FromSlash(dir)
-	files, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return fmt.Errorf("error listing oldest file, %v", err)
+	var owned []string
+	infos := map[string]os.FileInfo{}
+	collect := func(name string, info os.FileInfo) {
+		if isRevolverFile(n.prefix, info) {
+			owned = append(owned, name)
+			infos[name] = info
+		}
 	}
-	var oldest os.FileInfo
-	for _, info := range files {
-		if isRevolverFile(prefix, info) && isOlder(info, oldest) {
-			oldest = info
+	if n.partition == "" {
+		list, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range list {
+			collect(filepath.Join(dir, info.Name()), info)
 		}
+	} else {
+		err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			collect(name, info)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(owned, func(i, j int) bool {
+		return isOlder(infos[owned[i]], infos[owned[j]])
+	})
+	return owned, nil
+}
+
+// partitionDir returns the subdirectory for the given time layout and time.
+// Reserved characters within the path elements are replaced.
+func partitionDir(partition string, now time.Time) string {
+	elems := strings.Split(filepath.ToSlash(now.Format(partition)), "/")
+	for index, elem := range elems {
+		elems[index] = sanitize(elem)
+	}
+	return filepath.FromSlash(strings.Join(elems, "/"))
+}
+
+func fileCount(dir, prefix string) (int, error) {
+	files, err := prefixNaming{prefix: prefix}.files(dir)
+	if err != nil {
+		return 0, fmt.Errorf("error while counting files, %w", err)
 	}
-	if oldest != nil {
-		if err := os.Remove(filepath.Join(dir, oldest.Name())); err != nil {
-			return fmt.Errorf("error removing oldest file, %v", err)
+	return len(files), nil
+}
+
+func removeOldestFile(dir, prefix string) error {
+	files, err := prefixNaming{prefix: prefix}.files(dir)
+	if err != nil {
+		return fmt.Errorf("error listing oldest file, %w", err)
+	}
+	if len(files) > 0 {
+		if err := os.Remove(files[0]); err != nil {
+			return fmt.Errorf("error removing oldest file, %w", err)
 		}
 	}
 	return nil
 }
 
 func isRevolverFile(prefix string, file os.FileInfo) bool {
-	return !file.IsDir() && strings.HasPrefix(file.Name(), prefix)
+	return !file.IsDir() && strings.HasPrefix(file.Name(), prefix) && !isSidecar(file.Name())
 }
 
 func isOlder(test, old os.FileInfo) bool {
@@ -88,15 +169,54 @@ func isOlder(test, old os.FileInfo) bool {
 }
 
 func countAndRemoveFiles(dir, prefix string, maxFiles int) error {
-	count, err := fileCount(dir, prefix)
+	_, err := removeSurplus(dir, prefixNaming{prefix: prefix}, maxFiles)
+	return err
+}
+
+// removeSurplus removes the oldest files of the naming until there is space for one more file
+// and returns the number of removed files.
+func removeSurplus(dir string, n naming, maxFiles int) (int, error) {
+	files, err := n.files(dir)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("error while counting files, %w", err)
 	}
-	for maxFiles <= count {
-		if err := removeOldestFile(dir, prefix); err != nil {
-			return err
+	removed, err := removeOldest(dir, files, maxFiles-1)
+	forgetRemoved(n, files[:removed], err)
+	return removed, err
+}
+
+// removeOldest removes the oldest of the given files, with their sidecars, until keep files are left.
+// Files already removed by others are counted as removed.
+func removeOldest(dir string, files []string, keep int) (int, error) {
+	removed := 0
+	for len(files) > keep {
+		if err := os.Remove(files[0]); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("error removing oldest file, %w", err)
+		}
+		if err := os.Remove(files[0] + checksumExt); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("error removing oldest checksum, %w", err)
 		}
-		count--
+		if err := os.Remove(files[0] + pinExt); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("error removing oldest pin, %w", err)
+		}
+		pruneDirs(dir, files[0])
+		files = files[1:]
+		removed++
 	}
-	return nil
+	return removed, nil
+}
+
+// pruneDirs removes the empty parent directories of the removed file up to, but excluding, dir.
+func pruneDirs(dir, removed string) {
+	dir = filepath.Clean(filepath.FromSlash(dir))
+	for parent := filepath.Dir(removed); isBelow(dir, parent); parent = filepath.Dir(parent) {
+		if os.Remove(parent) != nil {
+			return // not empty
+		}
+	}
+}
+
+func isBelow(dir, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
@@ -465,6 +465,71 @@ func TestNewCountAndRemove(t *testing.T) {
 	}
 }
 
+func TestPartitionNaming(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	naming := prefixNaming{prefix: "log_", suffix: ".txt", middle: testMiddlePartFunc, partition: "2006/01/02"}
+	old := filepath.FromSlash("test/2001/02/03/log_old.txt")
+	logErr(os.MkdirAll(filepath.Dir(old), 0755), t)
+	logErr(ioutil.WriteFile(old, []byte{}, 0644), t)
+	logErr(os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)), t)
+	logErr(ioutil.WriteFile(filepath.FromSlash("test/2001/other.txt"), []byte{}, 0644), t)
+
+	file, err := naming.create("test")
+	logErr(err, t)
+	logErr(file.Close(), t)
+	exp := filepath.Join("test", time.Now().UTC().Format(filepath.FromSlash("2006/01/02")), "log_"+testMiddlePart+".txt")
+	if file.Name() != exp {
+		t.Errorf("exp file: '%s' got: '%s'", exp, file.Name())
+	}
+
+	files, err := naming.files("test")
+	logErr(err, t)
+	if len(files) != 2 || files[0] != old || files[1] != exp {
+		t.Fatalf("exp files: [%s %s] got: %v", old, exp, files)
+	}
+
+	removed, err := removeSurplus("test", naming, 2)
+	logErr(err, t)
+	if removed != 1 {
+		t.Errorf("exp removed: 1 got: %d", removed)
+	}
+	if _, err := os.Stat(filepath.FromSlash("test/2001/02")); !os.IsNotExist(err) {
+		t.Errorf("exp empty partition dirs to be removed got: %v", err)
+	}
+	if _, err := os.Stat(filepath.FromSlash("test/2001/other.txt")); err != nil {
+		t.Errorf("exp non empty dir to remain, %v", err)
+	}
+}
+
+func TestPartitionDir(t *testing.T) {
+	date := time.Date(2026, 10, 17, 13, 45, 1, 0, time.UTC)
+	got := partitionDir("2006/01/02/15:04", date)
+	exp := filepath.FromSlash("2026/10/17/13_45")
+	if got != exp {
+		t.Errorf("exp dir: '%s' got: '%s'", exp, got)
+	}
+}
+
+func TestPruneDirs(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	logErr(os.MkdirAll(filepath.FromSlash("test/a/b/c"), 0755), t)
+	pruneDirs("test", filepath.FromSlash("test/a/b/c/removed"))
+	if _, err := os.Stat(filepath.FromSlash("test/a")); !os.IsNotExist(err) {
+		t.Errorf("exp test/a to be removed got: %v", err)
+	}
+	if _, err := os.Stat("test"); err != nil {
+		t.Errorf("exp dir to remain, %v", err)
+	}
+	pruneDirs("test/a", filepath.FromSlash("test/removed"))
+	if _, err := os.Stat("test"); err != nil {
+		t.Errorf("exp dir outside to remain, %v", err)
+	}
+}
+
 func BenchmarkSetupDirs(b *testing.B) {
 	defer func() {
 		logBenchmarkErr(os.RemoveAll("test"), b)
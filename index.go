@@ -0,0 +1,106 @@
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// indexNaming keeps the files of a naming in memory, so a rotation doesn't list the whole dir.
+// The index is built by the first files call and maintained on create and remove.
+// It is built again after the resync interval, after a failed removal or on resync.
+type indexNaming struct {
+	naming
+	index  []string // oldest first
+	valid  bool
+	synced time.Time
+	resync time.Duration // 0 never
+	now    func() time.Time
+}
+
+func newIndexNaming(n naming, resync time.Duration) *indexNaming {
+	return &indexNaming{naming: n, resync: resync, now: time.Now}
+}
+
+func (n *indexNaming) create(dir string) (*os.File, error) {
+	file, err := n.naming.create(dir)
+	if err == nil && n.valid {
+		n.index = append(n.index, file.Name())
+	}
+	return file, err
+}
+
+func (n *indexNaming) files(dir string) ([]string, error) {
+	if !n.valid || (n.resync > 0 && n.now().Sub(n.synced) >= n.resync) {
+		if err := n.sync(dir); err != nil {
+			return nil, err
+		}
+	}
+	return append([]string{}, n.index...), nil
+}
+
+// sync builds the index from the files in dir.
+func (n *indexNaming) sync(dir string) error {
+	files, err := n.naming.files(dir)
+	if err != nil {
+		n.valid = false
+		return err
+	}
+	n.index, n.valid, n.synced = files, true, n.now()
+	return nil
+}
+
+// forget drops the removed files from the index.
+func (n *indexNaming) forget(removed []string) {
+	gone := map[string]bool{}
+	for _, name := range removed {
+		gone[name] = true
+	}
+	kept := n.index[:0]
+	for _, name := range n.index {
+		if !gone[name] {
+			kept = append(kept, name)
+		}
+	}
+	n.index = kept
+}
+
+// forgetRemoved drops the removed files from the index of the naming if it has one.
+// After a failed removal the files are listed again.
+func forgetRemoved(n naming, removed []string, err error) {
+	index, ok := n.(*indexNaming)
+	if !ok {
+		return
+	}
+	index.forget(removed)
+	if err != nil {
+		index.valid = false
+	}
+}
+
+// renameIndexed renames the file in the index of the naming if it has one.
+func renameIndexed(n naming, old, name string) {
+	index, ok := n.(*indexNaming)
+	if !ok {
+		return
+	}
+	for i, indexed := range index.index {
+		if indexed == old {
+			index.index[i] = name
+		}
+	}
+}
+
+// Resync lists the dir again if the writer keeps an index of its files, see Conf.Index.
+func (l *Writer) Resync() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	index, ok := l.naming.(*indexNaming)
+	if !ok {
+		return nil
+	}
+	if err := index.sync(l.dir); err != nil {
+		return fmt.Errorf("revolver, resync, %w", err)
+	}
+	return nil
+}
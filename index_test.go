@@ -0,0 +1,127 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIndexNaming(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	seq := 0
+	w, err := New(Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   func() string { seq++; return fmt.Sprintf("%03d", seq) },
+		MaxFiles: 3,
+		MaxBytes: 4,
+		Index:    true,
+	})
+	logErr(err, t)
+	rev := w.(*Writer)
+	write := func() {
+		_, err := w.Write([]byte("data"))
+		logErr(err, t)
+		time.Sleep(10 * time.Millisecond) // distinct mod times
+	}
+	for file := 0; file < 5; file++ {
+		write()
+	}
+	expFiles(t, "log_003", "log_004", "log_005")
+
+	// unknown to the index until resync
+	logErr(ioutil.WriteFile(filepath.Join("test", "log_ext"), nil, 0644), t)
+	time.Sleep(10 * time.Millisecond)
+	write()
+	expFiles(t, "log_004", "log_005", "log_ext", "log_006")
+	logErr(rev.Resync(), t)
+	write()
+	expFiles(t, "log_ext", "log_006", "log_007")
+
+	// removed by others
+	logErr(os.Remove(filepath.Join("test", "log_006")), t)
+	write()
+	write()
+	expFiles(t, "log_007", "log_008", "log_009")
+	logErr(w.Close(), t)
+}
+
+func TestIndexResync(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	logErr(os.Mkdir("test", 0755), t)
+	now := time.Now()
+	n := newIndexNaming(prefixNaming{prefix: "log_", middle: testMiddlePartFunc}, time.Minute)
+	n.now = func() time.Time { return now }
+	files, err := n.files("test")
+	logErr(err, t)
+	logErr(ioutil.WriteFile(filepath.Join("test", "log_a"), nil, 0644), t)
+	if files, err = n.files("test"); len(files) != 0 {
+		t.Errorf("exp index before resync interval got: %v, %v", files, err)
+	}
+	now = now.Add(time.Minute)
+	if files, err = n.files("test"); len(files) != 1 {
+		t.Errorf("exp resync after interval got: %v, %v", files, err)
+	}
+	if err := ValidConf(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 1, IndexResync: -1}); errStr(err) != "revolver conf.IndexResync must be >= 0" {
+		t.Errorf("exp index resync err got: %v", err)
+	}
+}
+
+func expFiles(t *testing.T, exp ...string) {
+	t.Helper()
+	files, err := prefixNaming{prefix: "log_"}.files("test")
+	logErr(err, t)
+	got := map[string]bool{}
+	for _, name := range files {
+		got[filepath.Base(name)] = true
+	}
+	for _, name := range exp {
+		if !got[name] {
+			t.Errorf("exp files: %v got: %v", exp, got)
+			return
+		}
+	}
+	if len(got) != len(exp) {
+		t.Errorf("exp files: %v got: %v", exp, got)
+	}
+}
+
+func BenchmarkRotation(b *testing.B) {
+	for _, unrelated := range []int{0, 1000, 5000} {
+		for _, index := range []bool{false, true} {
+			b.Run(fmt.Sprintf("unrelated=%d/index=%t", unrelated, index), func(b *testing.B) {
+				defer func() {
+					logBenchmarkErr(os.RemoveAll("test"), b)
+				}()
+				logBenchmarkErr(os.Mkdir("test", 0755), b)
+				for file := 0; file < unrelated; file++ {
+					logBenchmarkErr(ioutil.WriteFile(filepath.Join("test", "other_"+strconv.Itoa(file)), nil, 0644), b)
+				}
+				w, err := New(Conf{
+					Dir:      "test",
+					Prefix:   "log_",
+					Middle:   testCounterMiddle(),
+					MaxFiles: 3,
+					MaxBytes: 4,
+					Index:    index,
+				})
+				logBenchmarkErr(err, b)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, err := w.Write([]byte("data")) // every write rotates
+					logBenchmarkErr(err, b)
+				}
+				b.StopTimer()
+				logBenchmarkErr(w.Close(), b)
+			})
+		}
+	}
+}
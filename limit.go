@@ -0,0 +1,196 @@
+package revolver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LimitPolicy specifies what happens with writes over the rate limit.
+type LimitPolicy int
+
+const (
+	// LimitBlock blocks the write until the limit allows it. The writer is not locked meanwhile,
+	// so Close doesn't wait for it and the blocked write returns ErrClosed.
+	LimitBlock LimitPolicy = iota
+	// LimitDrop silently drops the write.
+	LimitDrop
+	// LimitMark drops the write and writes a single "revolver: N bytes suppressed" marker line
+	// before the next write allowed by the limit.
+	LimitMark
+)
+
+// Limit is a token bucket rate limit for writes and rotations, e. g. to stop a runaway
+// debug loop from rotating through all files. Dropped writes return len(p) and no error.
+type Limit struct {
+	BytesPerSecond     int // 0 is unlimited
+	Burst              int // optional max bytes at once, defaults to BytesPerSecond
+	RotationsPerMinute int // 0 is unlimited, the burst is one rotation
+	Policy             LimitPolicy
+}
+
+func validLimit(limit *Limit) error {
+	switch {
+	case limit.BytesPerSecond < 0:
+		return invalidConf("Limit.BytesPerSecond", "must be >= 0")
+	case limit.Burst < 0:
+		return invalidConf("Limit.Burst", "must be >= 0")
+	case limit.RotationsPerMinute < 0:
+		return invalidConf("Limit.RotationsPerMinute", "must be >= 0")
+	case limit.Policy < LimitBlock || limit.Policy > LimitMark:
+		return invalidConf("Limit.Policy", "is unknown")
+	}
+	return nil
+}
+
+// errSuppressed is returned by write if the limit suppressed a rotation.
+var errSuppressed = errors.New("revolver, suppressed by limit")
+
+// bucket is a token bucket which may be reserved below zero by blocking writes.
+type bucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst float64, now time.Time) *bucket {
+	return &bucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// allow takes n tokens if available.
+func (b *bucket) allow(n float64, now time.Time) bool {
+	b.refill(now)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// reserve takes n tokens, the bucket is below zero if they are not available.
+func (b *bucket) reserve(n float64, now time.Time) {
+	b.refill(now)
+	b.tokens -= n
+}
+
+// delay returns how long to wait until n tokens are available, at most the burst.
+func (b *bucket) delay(n float64, now time.Time) time.Duration {
+	b.refill(now)
+	if n > b.burst {
+		n = b.burst
+	}
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+type limiter struct {
+	policy     LimitPolicy
+	bytes      *bucket // nil if unlimited
+	rotations  *bucket // nil if unlimited
+	suppressed int
+	now        func() time.Time
+	sleep      func(time.Duration)
+}
+
+func newLimiter(limit *Limit) *limiter {
+	if limit == nil {
+		return nil
+	}
+	l := &limiter{policy: limit.Policy, now: time.Now, sleep: time.Sleep}
+	now := l.now()
+	if limit.BytesPerSecond > 0 {
+		burst := limit.Burst
+		if burst == 0 {
+			burst = limit.BytesPerSecond
+		}
+		l.bytes = newBucket(float64(limit.BytesPerSecond), float64(burst), now)
+	}
+	if limit.RotationsPerMinute > 0 {
+		l.rotations = newBucket(float64(limit.RotationsPerMinute)/60, 1, now)
+	}
+	return l
+}
+
+// admit reports whether n bytes may be written, blocking policies reserve them.
+func (l *limiter) admit(n int) bool {
+	return l.take(l.bytes, float64(n))
+}
+
+// rotate reports whether a new file may be created, blocking policies reserve it.
+func (l *limiter) rotate() bool {
+	return l.take(l.rotations, 1)
+}
+
+func (l *limiter) take(b *bucket, n float64) bool {
+	if b == nil {
+		return true
+	}
+	if l.policy == LimitBlock {
+		b.reserve(n, l.now())
+		return true
+	}
+	return b.allow(n, l.now())
+}
+
+// delay returns how long a blocking policy waits until n bytes and with rotate a new file are
+// available.
+func (l *limiter) delay(n int, rotate bool) time.Duration {
+	if l.policy != LimitBlock {
+		return 0
+	}
+	var wait time.Duration
+	if l.bytes != nil {
+		wait = l.bytes.delay(float64(n), l.now())
+	}
+	if rotate && l.rotations != nil {
+		if rotation := l.rotations.delay(1, l.now()); rotation > wait {
+			wait = rotation
+		}
+	}
+	return wait
+}
+
+// waitLimit releases the lock while a blocking limit waits for n bytes and the next file if
+// they don't fit into the current file. It returns the stopped error if the writer was stopped
+// meanwhile.
+func (l *Writer) waitLimit(n int) error {
+	for l.limit != nil {
+		rotate := l.file == nil || l.size+l.sizeOf(n) > l.maxBytes
+		wait := l.limit.delay(n, rotate)
+		if wait <= 0 {
+			return nil
+		}
+		l.lock.Unlock()
+		l.limit.sleep(wait)
+		l.lock.Lock()
+		if l.stopped != nil {
+			return l.stopped
+		}
+	}
+	return nil
+}
+
+func (l *limiter) suppress(n int) {
+	if l.policy == LimitMark {
+		l.suppressed += n
+	}
+}
+
+// marker returns the marker line for the suppressed bytes or nil.
+func (l *limiter) marker() []byte {
+	if l.suppressed == 0 {
+		return nil
+	}
+	return []byte(fmt.Sprintf("revolver: %d bytes suppressed\n", l.suppressed))
+}
@@ -0,0 +1,173 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClock is a fake clock for the limiter which advances on sleep.
+type testClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *testClock) sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept += d
+}
+
+func newLimitedWriter(t *testing.T, limit Limit, maxBytes int) (*Writer, *testClock) {
+	w, err := New(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 10, MaxBytes: maxBytes, Limit: &limit})
+	logErr(err, t)
+	rev := w.(*Writer)
+	clock := &testClock{now: time.Now()}
+	rev.limit.now = func() time.Time { return clock.now }
+	rev.limit.sleep = clock.sleep
+	for _, b := range []*bucket{rev.limit.bytes, rev.limit.rotations} {
+		if b != nil {
+			b.last = clock.now
+		}
+	}
+	return rev, clock
+}
+
+func readAll(t *testing.T) string {
+	files, err := prefixNaming{prefix: "log_"}.files("test")
+	logErr(err, t)
+	content := ""
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		logErr(err, t)
+		content += string(data)
+	}
+	return content
+}
+
+func TestLimitBytes(t *testing.T) {
+	var tests = []struct {
+		policy LimitPolicy
+		exp    string
+		slept  time.Duration
+	}{
+		{policy: LimitBlock, exp: "aaaaabbbbbcccccddddd", slept: 500 * time.Millisecond},
+		{policy: LimitDrop, exp: "aaaaabbbbbddddd"},
+		{policy: LimitMark, exp: "aaaaabbbbbrevolver: 5 bytes suppressed\nddddd"},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. policy: %d", index, test.policy), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			w, clock := newLimitedWriter(t, Limit{BytesPerSecond: 10, Policy: test.policy}, 100)
+			for _, p := range []string{"aaaaa", "bbbbb", "ccccc"} {
+				n, err := w.Write([]byte(p))
+				logErrAt(err, index, t)
+				if n != len(p) {
+					t.Errorf("%d. exp n: %d got: %d", index, len(p), n)
+				}
+			}
+			clock.sleep(2 * time.Second)
+			_, err := w.Write([]byte("ddddd"))
+			logErrAt(err, index, t)
+			logErrAt(w.Close(), index, t)
+
+			if got := readAll(t); got != test.exp {
+				t.Errorf("%d. exp content: '%s' got: '%s'", index, test.exp, got)
+			}
+			if got := clock.slept - 2*time.Second; got != test.slept {
+				t.Errorf("%d. exp to block: %v got: %v", index, test.slept, got)
+			}
+		})
+	}
+}
+
+func TestLimitBlockClose(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, _ := newLimitedWriter(t, Limit{BytesPerSecond: 5, Policy: LimitBlock}, 100)
+	waiting, wake := make(chan struct{}), make(chan struct{})
+	w.limit.sleep = func(time.Duration) {
+		close(waiting)
+		<-wake
+	}
+	_, err := w.Write([]byte("aaaaa"))
+	logErr(err, t)
+	blocked := make(chan error)
+	go func() {
+		_, err := w.Write([]byte("bbbbb"))
+		blocked <- err
+	}()
+	<-waiting
+	logErr(w.Close(), t) // doesn't wait for the blocked write
+	close(wake)
+	if err := <-blocked; err != ErrClosed {
+		t.Errorf("exp blocked write to return closed err got: %v", err)
+	}
+	if got := readAll(t); got != "aaaaa" {
+		t.Errorf("exp content: 'aaaaa' got: '%s'", got)
+	}
+}
+
+func TestLimitRotations(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	limit := Limit{RotationsPerMinute: 1, Policy: LimitMark}
+	w, err := New(Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   testCounterMiddle(),
+		MaxFiles: 10,
+		MaxBytes: 40,
+		Limit:    &limit,
+	})
+	logErr(err, t)
+	rev := w.(*Writer)
+	clock := &testClock{now: time.Now()}
+	rev.limit.now = func() time.Time { return clock.now }
+	rev.limit.rotations.last = clock.now
+
+	for write := 0; write < 10; write++ {
+		_, err := w.Write([]byte("0123456789"))
+		logErr(err, t)
+	}
+	clock.sleep(time.Minute)
+	_, err = w.Write([]byte("after"))
+	logErr(err, t)
+	logErr(w.Close(), t)
+
+	count, err := fileCount("test", "log_")
+	logErr(err, t)
+	if count != 3 {
+		t.Errorf("exp one rotation per minute to create 3 files got: %d", count)
+	}
+	content := readAll(t)
+	if !strings.HasSuffix(content, "0123456789revolver: 20 bytes suppressed\nafter") {
+		t.Errorf("exp suppressed marker before 'after' got: '%s'", content)
+	}
+}
+
+func TestValidLimit(t *testing.T) {
+	var tests = []struct {
+		limit Limit
+		err   string
+	}{
+		{limit: Limit{BytesPerSecond: 1, RotationsPerMinute: 1, Policy: LimitMark}},
+		{limit: Limit{BytesPerSecond: -1}, err: "revolver conf.Limit.BytesPerSecond must be >= 0"},
+		{limit: Limit{Burst: -1}, err: "revolver conf.Limit.Burst must be >= 0"},
+		{limit: Limit{RotationsPerMinute: -1}, err: "revolver conf.Limit.RotationsPerMinute must be >= 0"},
+		{limit: Limit{Policy: LimitMark + 1}, err: "revolver conf.Limit.Policy is unknown"},
+	}
+	for index, test := range tests {
+		conf := DefaultConf()
+		conf.Limit = &test.limit
+		if err := ValidConf(conf); errStr(err) != test.err {
+			t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package revolver
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	envDir       = "DIR"
+	envPrefix    = "PREFIX"
+	envSuffix    = "SUFFIX"
+	envPartition = "PARTITION"
+	envMiddle    = "MIDDLE"
+	envMaxFiles  = "MAX_FILES"
+	envMaxBytes  = "MAX_BYTES"
+)
+
+// ConfFromEnv returns the DefaultConf overwritten by the set environment variables.
+// The given prefix is prepended to every variable name as is, e. g. with the prefix
+// "APP_LOG_" the variables APP_LOG_DIR, APP_LOG_PREFIX, APP_LOG_SUFFIX, APP_LOG_PARTITION,
+// APP_LOG_MIDDLE, APP_LOG_MAX_FILES and APP_LOG_MAX_BYTES are read.
+// The MIDDLE variable accepts the same values as the -revolver-middle flag.
+// The returned conf is validated with ValidConf.
+func ConfFromEnv(prefix string) (Conf, error) {
+	conf := DefaultConf()
+	if val, ok := os.LookupEnv(prefix + envDir); ok {
+		conf.Dir = val
+	}
+	if val, ok := os.LookupEnv(prefix + envPrefix); ok {
+		conf.Prefix = val
+	}
+	if val, ok := os.LookupEnv(prefix + envSuffix); ok {
+		conf.Suffix = val
+	}
+	if val, ok := os.LookupEnv(prefix + envPartition); ok {
+		conf.Partition = val
+	}
+	if val, ok := os.LookupEnv(prefix + envMiddle); ok {
+		middle, err := middleFromFormat(val)
+		if err != nil {
+			return Conf{}, err
+		}
+		conf.Middle = middle
+	}
+	if val, ok := os.LookupEnv(prefix + envMaxFiles); ok {
+		count, err := parseCount("MaxFiles", val)
+		if err != nil {
+			return Conf{}, err
+		}
+		conf.MaxFiles = count
+	}
+	if val, ok := os.LookupEnv(prefix + envMaxBytes); ok {
+		count, err := parseCount("MaxBytes", val)
+		if err != nil {
+			return Conf{}, err
+		}
+		conf.MaxBytes = count
+	}
+	if err := ValidConf(conf); err != nil {
+		return Conf{}, err
+	}
+	return conf, nil
+}
+
+// RegisterFlags registers flags for all conf fields on the given flag set.
+// The current conf values are used as flag defaults, so a typical setup is:
+//
+//	conf := revolver.DefaultConf()
+//	conf.RegisterFlags(flag.CommandLine)
+//	flag.Parse()
+//
+// Registered flags: -revolver-dir, -revolver-prefix, -revolver-suffix, -revolver-partition,
+// -revolver-middle, -revolver-max-files and -revolver-max-bytes.
+// Invalid counts and middle formats are reported by the flag set on parsing.
+func (c *Conf) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Dir, "revolver-dir", c.Dir, "directory to write the files to")
+	fs.StringVar(&c.Prefix, "revolver-prefix", c.Prefix, "file name prefix, used to identify surplus files to delete")
+	fs.StringVar(&c.Suffix, "revolver-suffix", c.Suffix, "file name suffix")
+	fs.StringVar(&c.Partition, "revolver-partition", c.Partition, "optional UTC time layout of subdirectories e. g. 2006/01/02")
+	fs.Var(&middleFlag{conf: c, format: middleDate}, "revolver-middle",
+		"file name middle part: '"+middleDate+"', '"+middleUTC+"', '"+middleUnixNano+"', '"+
+			middleHostPID+"', '"+middleNone+"' or a time layout")
+	fs.Var(countFlag{field: "MaxFiles", val: &c.MaxFiles}, "revolver-max-files", "max number of files to keep")
+	fs.Var(countFlag{field: "MaxBytes", val: &c.MaxBytes}, "revolver-max-bytes", "max bytes per file")
+}
+
+const (
+	middleDate     = "date"
+	middleNone     = "none"
+	middleUTC      = "utc"
+	middleUnixNano = "unix-nano"
+	middleHostPID  = "host-pid"
+)
+
+// middleFromFormat returns the middle function for the given format.
+// An empty format or "date" selects DateStringMiddle, "none" an empty middle,
+// "utc" UTCMiddle, "unix-nano" UnixNanoMiddle, "host-pid" HostPIDMiddle and
+// every other value is used as time layout.
+func middleFromFormat(format string) (func() string, error) {
+	switch format {
+	case "", middleDate:
+		return DateStringMiddle, nil
+	case middleNone:
+		return func() string { return "" }, nil
+	case middleUTC:
+		return UTCMiddle, nil
+	case middleUnixNano:
+		return UnixNanoMiddle, nil
+	case middleHostPID:
+		return HostPIDMiddle, nil
+	}
+	if !isTimeLayout(format) {
+		return nil, invalidConf("Middle", "format '%s' is no time layout", format)
+	}
+	return FormatMiddle(format, nil), nil
+}
+
+func parseCount(field, val string) (int, error) {
+	count, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return 0, invalidConf(field, "must be a number")
+	}
+	if count < 1 {
+		return 0, invalidConf(field, "must be > 0")
+	}
+	return count, nil
+}
+
+type countFlag struct {
+	field string
+	val   *int
+}
+
+func (f countFlag) String() string {
+	if f.val == nil {
+		return "0"
+	}
+	return strconv.Itoa(*f.val)
+}
+
+func (f countFlag) Set(val string) error {
+	count, err := parseCount(f.field, val)
+	if err != nil {
+		return err
+	}
+	*f.val = count
+	return nil
+}
+
+type middleFlag struct {
+	conf   *Conf
+	format string
+}
+
+func (f *middleFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.format
+}
+
+func (f *middleFlag) Set(val string) error {
+	middle, err := middleFromFormat(val)
+	if err != nil {
+		return err
+	}
+	f.conf.Middle = middle
+	f.format = val
+	return nil
+}
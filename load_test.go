@@ -0,0 +1,176 @@
+package revolver
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfFromEnv(t *testing.T) {
+	var tests = []struct {
+		env      map[string]string
+		dir      string
+		prefix   string
+		suffix   string
+		maxFiles int
+		maxBytes int
+		middle   string
+		err      string
+	}{
+		{
+			env:      map[string]string{},
+			dir:      defaultDir,
+			prefix:   defaultPrefix,
+			suffix:   defaultSuffix,
+			maxFiles: defaultMaxFiles,
+			maxBytes: defaultMaxBytes,
+			middle:   DateStringMiddle(),
+		},
+		{
+			env: map[string]string{
+				"TEST_LOG_DIR":       "logs",
+				"TEST_LOG_PREFIX":    "app_",
+				"TEST_LOG_SUFFIX":    "",
+				"TEST_LOG_MIDDLE":    "none",
+				"TEST_LOG_MAX_FILES": "7",
+				"TEST_LOG_MAX_BYTES": " 1024 ",
+			},
+			dir:      "logs",
+			prefix:   "app_",
+			suffix:   "",
+			maxFiles: 7,
+			maxBytes: 1024,
+			middle:   "",
+		},
+		{
+			env: map[string]string{"TEST_LOG_MAX_FILES": "many"},
+			err: "revolver conf.MaxFiles must be a number",
+		},
+		{
+			env: map[string]string{"TEST_LOG_MAX_BYTES": "0"},
+			err: "revolver conf.MaxBytes must be > 0",
+		},
+		{
+			env: map[string]string{"TEST_LOG_MIDDLE": "static"},
+			err: "revolver conf.Middle format 'static' is no time layout",
+		},
+		{
+			env: map[string]string{"TEST_LOG_PREFIX": ""},
+			err: "revolver conf.Prefix can not be empty",
+		},
+	}
+
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. conf from env err: %v", index, test.err), func(t *testing.T) {
+			for key, val := range test.env {
+				logErr(os.Setenv(key, val), t)
+			}
+			defer func() {
+				for key := range test.env {
+					logErr(os.Unsetenv(key), t)
+				}
+			}()
+
+			conf, err := ConfFromEnv("TEST_LOG_")
+			if errStr(err) != test.err {
+				t.Fatalf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			if test.err != "" {
+				return // test done
+			}
+			if conf.Dir != test.dir {
+				t.Errorf("%d. exp dir: '%s' got: '%s'", index, test.dir, conf.Dir)
+			}
+			if conf.Prefix != test.prefix {
+				t.Errorf("%d. exp prefix: '%s' got: '%s'", index, test.prefix, conf.Prefix)
+			}
+			if conf.Suffix != test.suffix {
+				t.Errorf("%d. exp suffix: '%s' got: '%s'", index, test.suffix, conf.Suffix)
+			}
+			if conf.MaxFiles != test.maxFiles {
+				t.Errorf("%d. exp max files: %d got: %d", index, test.maxFiles, conf.MaxFiles)
+			}
+			if conf.MaxBytes != test.maxBytes {
+				t.Errorf("%d. exp max bytes: %d got: %d", index, test.maxBytes, conf.MaxBytes)
+			}
+			if got := conf.Middle(); got != test.middle {
+				t.Errorf("%d. exp middle: '%s' got: '%s'", index, test.middle, got)
+			}
+		})
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	var tests = []struct {
+		args     []string
+		dir      string
+		maxFiles int
+		maxBytes int
+		err      string
+	}{
+		{
+			args:     []string{},
+			dir:      defaultDir,
+			maxFiles: defaultMaxFiles,
+			maxBytes: defaultMaxBytes,
+		},
+		{
+			args:     []string{"-revolver-dir", "logs", "-revolver-max-files", "5", "-revolver-max-bytes=10"},
+			dir:      "logs",
+			maxFiles: 5,
+			maxBytes: 10,
+		},
+		{
+			args: []string{"-revolver-max-files", "-1"},
+			err:  "revolver conf.MaxFiles must be > 0",
+		},
+		{
+			args: []string{"-revolver-max-bytes", "ten"},
+			err:  "revolver conf.MaxBytes must be a number",
+		},
+		{
+			args: []string{"-revolver-middle", "static"},
+			err:  "revolver conf.Middle format 'static' is no time layout",
+		},
+	}
+
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. register flags err: %v", index, test.err), func(t *testing.T) {
+			t.Parallel()
+			conf := DefaultConf()
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(ioutil.Discard)
+			conf.RegisterFlags(fs)
+
+			err := fs.Parse(test.args)
+			if !strings.Contains(errStr(err), test.err) {
+				t.Fatalf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			if test.err != "" {
+				return // test done
+			}
+			if conf.Dir != test.dir {
+				t.Errorf("%d. exp dir: '%s' got: '%s'", index, test.dir, conf.Dir)
+			}
+			if conf.MaxFiles != test.maxFiles {
+				t.Errorf("%d. exp max files: %d got: %d", index, test.maxFiles, conf.MaxFiles)
+			}
+			if conf.MaxBytes != test.maxBytes {
+				t.Errorf("%d. exp max bytes: %d got: %d", index, test.maxBytes, conf.MaxBytes)
+			}
+		})
+	}
+}
+
+func TestMiddleFromFormat(t *testing.T) {
+	middle, err := middleFromFormat("2006-01-02 15:04")
+	logErr(err, t)
+	if got := middle(); strings.Contains(got, ":") {
+		t.Errorf("exp middle without ':' got: '%s'", got)
+	}
+}
@@ -0,0 +1,195 @@
+package revolver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives the events of a writer. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Written is called for every successful write with the written bytes and the write latency.
+	Written(bytes int, latency time.Duration)
+	// WriteFailed is called for every failed write.
+	WriteFailed()
+	// Rotated is called for every new file created by a write.
+	Rotated()
+	// Removed is called with the number of surplus files removed before a new file is created.
+	Removed(files int)
+	// FileSize is called with the current file size after every write.
+	FileSize(bytes int)
+	// Redacted is called with the number of redactions of every record if redactions are configured.
+	Redacted(count int)
+}
+
+type noMetrics struct{}
+
+func (noMetrics) Written(int, time.Duration) {}
+func (noMetrics) WriteFailed()               {}
+func (noMetrics) Rotated()                   {}
+func (noMetrics) Removed(int)                {}
+func (noMetrics) FileSize(int)               {}
+func (noMetrics) Redacted(int)               {}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the write latency histogram.
+var DefaultLatencyBuckets = []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1}
+
+// WriterMetrics is a Metrics implementation which counts all events of a writer.
+type WriterMetrics struct {
+	name      string
+	bytes     int64
+	writes    int64
+	rotations int64
+	removed   int64
+	errors    int64
+	size      int64
+	redacted  int64
+
+	lock    *sync.Mutex // synchronizes the histogram
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewMetrics returns WriterMetrics with the DefaultLatencyBuckets.
+// The name is exposed as writer label to distinguish several writers.
+func NewMetrics(name string) *WriterMetrics {
+	return &WriterMetrics{
+		name:    name,
+		lock:    &sync.Mutex{},
+		buckets: DefaultLatencyBuckets,
+		counts:  make([]uint64, len(DefaultLatencyBuckets)),
+	}
+}
+
+// Written counts the bytes, the write and its latency.
+func (m *WriterMetrics) Written(bytes int, latency time.Duration) {
+	atomic.AddInt64(&m.bytes, int64(bytes))
+	atomic.AddInt64(&m.writes, 1)
+
+	seconds := latency.Seconds()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for index, bound := range m.buckets {
+		if seconds <= bound {
+			m.counts[index]++
+		}
+	}
+	m.sum += seconds
+	m.count++
+}
+
+// WriteFailed counts the write error.
+func (m *WriterMetrics) WriteFailed() {
+	atomic.AddInt64(&m.errors, 1)
+}
+
+// Rotated counts the rotation.
+func (m *WriterMetrics) Rotated() {
+	atomic.AddInt64(&m.rotations, 1)
+}
+
+// Removed counts the removed files.
+func (m *WriterMetrics) Removed(files int) {
+	atomic.AddInt64(&m.removed, int64(files))
+}
+
+// FileSize sets the current file size.
+func (m *WriterMetrics) FileSize(bytes int) {
+	atomic.StoreInt64(&m.size, int64(bytes))
+}
+
+// Redacted counts the redactions.
+func (m *WriterMetrics) Redacted(count int) {
+	atomic.AddInt64(&m.redacted, int64(count))
+}
+
+// BytesWritten returns the count of written bytes.
+func (m *WriterMetrics) BytesWritten() int64 { return atomic.LoadInt64(&m.bytes) }
+
+// Writes returns the count of successful writes.
+func (m *WriterMetrics) Writes() int64 { return atomic.LoadInt64(&m.writes) }
+
+// Rotations returns the count of rotations.
+func (m *WriterMetrics) Rotations() int64 { return atomic.LoadInt64(&m.rotations) }
+
+// FilesRemoved returns the count of removed files.
+func (m *WriterMetrics) FilesRemoved() int64 { return atomic.LoadInt64(&m.removed) }
+
+// WriteErrors returns the count of failed writes.
+func (m *WriterMetrics) WriteErrors() int64 { return atomic.LoadInt64(&m.errors) }
+
+// CurrentFileSize returns the size of the current file.
+func (m *WriterMetrics) CurrentFileSize() int64 { return atomic.LoadInt64(&m.size) }
+
+// Redactions returns the count of redactions.
+func (m *WriterMetrics) Redactions() int64 { return atomic.LoadInt64(&m.redacted) }
+
+type metricFamily struct {
+	name  string
+	help  string
+	kind  string
+	value func(m *WriterMetrics) int64
+}
+
+var metricFamilies = []metricFamily{
+	{name: "revolver_written_bytes_total", help: "Bytes written.", kind: "counter", value: (*WriterMetrics).BytesWritten},
+	{name: "revolver_writes_total", help: "Successful writes.", kind: "counter", value: (*WriterMetrics).Writes},
+	{name: "revolver_write_errors_total", help: "Failed writes.", kind: "counter", value: (*WriterMetrics).WriteErrors},
+	{name: "revolver_rotations_total", help: "Files created by writes.", kind: "counter", value: (*WriterMetrics).Rotations},
+	{name: "revolver_removed_files_total", help: "Surplus files removed.", kind: "counter", value: (*WriterMetrics).FilesRemoved},
+	{name: "revolver_redactions_total", help: "Redacted parts of records.", kind: "counter", value: (*WriterMetrics).Redactions},
+	{name: "revolver_file_size_bytes", help: "Size of the current file.", kind: "gauge", value: (*WriterMetrics).CurrentFileSize},
+}
+
+const latencyFamily = "revolver_write_duration_seconds"
+
+// WritePrometheus writes the given metrics in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, metrics ...*WriterMetrics) error {
+	ew := &errWriter{w: w}
+	for _, family := range metricFamilies {
+		ew.printf("# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.kind)
+		for _, m := range metrics {
+			ew.printf("%s{writer=%q} %d\n", family.name, m.name, family.value(m))
+		}
+	}
+	ew.printf("# HELP %s Write latency.\n# TYPE %s histogram\n", latencyFamily, latencyFamily)
+	for _, m := range metrics {
+		m.lock.Lock()
+		for index, bound := range m.buckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			ew.printf("%s_bucket{writer=%q,le=%q} %d\n", latencyFamily, m.name, le, m.counts[index])
+		}
+		ew.printf("%s_bucket{writer=%q,le=\"+Inf\"} %d\n", latencyFamily, m.name, m.count)
+		ew.printf("%s_sum{writer=%q} %s\n", latencyFamily, m.name, strconv.FormatFloat(m.sum, 'g', -1, 64))
+		ew.printf("%s_count{writer=%q} %d\n", latencyFamily, m.name, m.count)
+		m.lock.Unlock()
+	}
+	return ew.err
+}
+
+// MetricsHandler returns a http.Handler serving the given metrics in the Prometheus text exposition format.
+func MetricsHandler(metrics ...*WriterMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, metrics...)
+	})
+}
+
+// errWriter keeps the first write error and skips all following writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
@@ -0,0 +1,92 @@
+package revolver
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterMetrics(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	metrics := NewMetrics("app")
+	conf := Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   UnixNanoMiddle,
+		MaxFiles: 2,
+		MaxBytes: 4,
+		Metrics:  metrics,
+	}
+	w, err := New(conf)
+	logErr(err, t)
+	defer w.Close()
+	for _, data := range []string{"123", "4", "56", "789", "too large"} {
+		w.Write([]byte(data))
+	}
+
+	var tests = []struct {
+		name string
+		exp  int64
+		got  int64
+	}{
+		{name: "bytes", exp: 9, got: metrics.BytesWritten()},
+		{name: "writes", exp: 4, got: metrics.Writes()},
+		{name: "errors", exp: 1, got: metrics.WriteErrors()},
+		{name: "rotations", exp: 2, got: metrics.Rotations()},
+		{name: "removed", exp: 1, got: metrics.FilesRemoved()},
+		{name: "size", exp: 3, got: metrics.CurrentFileSize()},
+	}
+	for _, test := range tests {
+		if test.got != test.exp {
+			t.Errorf("exp %s: %d got: %d", test.name, test.exp, test.got)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	metrics := NewMetrics("app")
+	metrics.Written(10, 2*time.Millisecond)
+	metrics.Written(5, 2*time.Second)
+	metrics.Rotated()
+	metrics.Removed(3)
+	metrics.FileSize(15)
+	metrics.WriteFailed()
+	metrics.Redacted(2)
+
+	rec := httptest.NewRecorder()
+	MetricsHandler(metrics, NewMetrics("other")).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("exp prometheus content type got: '%s'", got)
+	}
+	body := rec.Body.String()
+	for _, exp := range []string{
+		"# TYPE revolver_written_bytes_total counter\n",
+		`revolver_written_bytes_total{writer="app"} 15`,
+		`revolver_written_bytes_total{writer="other"} 0`,
+		`revolver_writes_total{writer="app"} 2`,
+		`revolver_write_errors_total{writer="app"} 1`,
+		`revolver_rotations_total{writer="app"} 1`,
+		`revolver_removed_files_total{writer="app"} 3`,
+		`revolver_redactions_total{writer="app"} 2`,
+		"# TYPE revolver_file_size_bytes gauge\n",
+		`revolver_file_size_bytes{writer="app"} 15`,
+		"# TYPE revolver_write_duration_seconds histogram\n",
+		`revolver_write_duration_seconds_bucket{writer="app",le="0.001"} 0`,
+		`revolver_write_duration_seconds_bucket{writer="app",le="0.005"} 1`,
+		`revolver_write_duration_seconds_bucket{writer="app",le="1"} 1`,
+		`revolver_write_duration_seconds_bucket{writer="app",le="+Inf"} 2`,
+		`revolver_write_duration_seconds_sum{writer="app"} 2.002`,
+		`revolver_write_duration_seconds_count{writer="app"} 2`,
+	} {
+		if !strings.Contains(body, exp) {
+			t.Errorf("exp body to contain '%s' got:\n%s", exp, body)
+		}
+	}
+	if count := strings.Count(body, "# TYPE revolver_writes_total"); count != 1 {
+		t.Errorf("exp one type line per family got: %d", count)
+	}
+}
@@ -0,0 +1,116 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	utcLayout     = "20060102T150405.000Z"
+	unixNanoWidth = 19
+)
+
+// UTCMiddle returns the current UTC time as ISO-8601 basic format timestamp
+// with millisecond precision e. g. 20261017T134501.123Z.
+// The timestamp contains no reserved characters and sorts lexicographically.
+func UTCMiddle() string {
+	return time.Now().UTC().Format(utcLayout)
+}
+
+// UnixNanoMiddle returns the current Unix time in nanoseconds.
+// The number is zero padded to 19 digits, so it sorts lexicographically.
+func UnixNanoMiddle() string {
+	nanos := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if len(nanos) < unixNanoWidth {
+		nanos = strings.Repeat("0", unixNanoWidth-len(nanos)) + nanos
+	}
+	return nanos
+}
+
+// HostPIDMiddle returns the hostname and the process id e. g. myhost-4711.
+// If the hostname can not be determined "unknown" is used instead.
+func HostPIDMiddle() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return sanitize(host) + "-" + strconv.Itoa(os.Getpid())
+}
+
+// FormatMiddle returns a middle function which formats the current time in the
+// given location with the given time.Format layout. If loc is nil time.Local is used.
+// Characters which are reserved in file names on any platform are replaced with '_'.
+func FormatMiddle(layout string, loc *time.Location) func() string {
+	if loc == nil {
+		loc = time.Local
+	}
+	return func() string {
+		return sanitize(time.Now().In(loc).Format(layout))
+	}
+}
+
+// CounterMiddle returns a middle function which returns a monotonically increasing,
+// zero padded counter e. g. 0000000001. The last value is persisted in the given
+// state file, so the counter continues after a restart.
+// CAUTION the state file should not be placed in the revolver dir with the revolver prefix,
+// otherwise it will eventually be deleted.
+// Errors persisting the counter after creation are ignored, the counter keeps increasing in memory.
+func CounterMiddle(stateFile string) (func() string, error) {
+	count, err := readCounter(stateFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCounter(stateFile, count); err != nil {
+		return nil, err
+	}
+	lock := &sync.Mutex{}
+	return func() string {
+		lock.Lock()
+		defer lock.Unlock()
+		count++
+		_ = writeCounter(stateFile, count)
+		return fmt.Sprintf("%010d", count)
+	}, nil
+}
+
+func readCounter(stateFile string) (uint64, error) {
+	data, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading counter file, %w", err)
+	}
+	count, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing counter file, %w", err)
+	}
+	return count, nil
+}
+
+func writeCounter(stateFile string, count uint64) error {
+	tmp := stateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.FormatUint(count, 10)), 0644); err != nil {
+		return fmt.Errorf("error writing counter file, %w", err)
+	}
+	if err := os.Rename(tmp, stateFile); err != nil {
+		return fmt.Errorf("error writing counter file, %w", err)
+	}
+	return nil
+}
+
+// sanitize replaces all characters which are reserved in file names
+// on Windows, Linux or Mac with '_'.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,103 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUTCMiddle(t *testing.T) {
+	first := UTCMiddle()
+	time.Sleep(2 * time.Millisecond)
+	second := UTCMiddle()
+	if first >= second {
+		t.Errorf("exp '%s' to sort before '%s'", first, second)
+	}
+	if _, err := time.Parse(utcLayout, second); err != nil {
+		t.Errorf("exp parsable utc middle got: '%s', %v", second, err)
+	}
+}
+
+func TestUnixNanoMiddle(t *testing.T) {
+	got := UnixNanoMiddle()
+	if len(got) != unixNanoWidth {
+		t.Errorf("exp len: %d got: %d", unixNanoWidth, len(got))
+	}
+	if _, err := strconv.ParseInt(got, 10, 64); err != nil {
+		t.Errorf("exp number got: '%s'", got)
+	}
+}
+
+func TestHostPIDMiddle(t *testing.T) {
+	got := HostPIDMiddle()
+	exp := "-" + strconv.Itoa(os.Getpid())
+	if !strings.HasSuffix(got, exp) {
+		t.Errorf("exp suffix: '%s' got: '%s'", exp, got)
+	}
+}
+
+func TestFormatMiddle(t *testing.T) {
+	var tests = []struct {
+		layout string
+		exp    string
+	}{
+		{layout: "2006-01-02", exp: "2001-02-03"},
+		{layout: "15:04:05", exp: "04_05_06"},
+		{layout: "2006/01/02", exp: "2001_02_03"},
+		{layout: `"Jan" <2> |15| ?*\`, exp: "_Feb_ _3_ _04_ ___"},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. format: '%s'", index, test.layout), func(t *testing.T) {
+			t.Parallel()
+			date := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+			got := sanitize(date.Format(test.layout))
+			if got != test.exp {
+				t.Errorf("%d. exp: '%s' got: '%s'", index, test.exp, got)
+			}
+		})
+	}
+
+	loc := time.FixedZone("test", -12*60*60)
+	got := FormatMiddle("2006-01-02", loc)()
+	exp := time.Now().In(loc).Format("2006-01-02")
+	if got != exp {
+		t.Errorf("exp: '%s' got: '%s'", exp, got)
+	}
+}
+
+func TestCounterMiddle(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	logErr(os.Mkdir("test", 0755), t)
+	state := filepath.FromSlash("test/counter")
+
+	middle, err := CounterMiddle(state)
+	logErr(err, t)
+	if got := middle(); got != "0000000001" {
+		t.Errorf("exp first count: '0000000001' got: '%s'", got)
+	}
+	if got := middle(); got != "0000000002" {
+		t.Errorf("exp second count: '0000000002' got: '%s'", got)
+	}
+
+	restarted, err := CounterMiddle(state)
+	logErr(err, t)
+	if got := restarted(); got != "0000000003" {
+		t.Errorf("exp count after restart: '0000000003' got: '%s'", got)
+	}
+
+	logErr(ioutil.WriteFile(state, []byte("broken"), 0644), t)
+	if _, err := CounterMiddle(state); !strings.HasPrefix(errStr(err), "error parsing counter file,") {
+		t.Errorf("exp parsing err got: '%v'", err)
+	}
+	if _, err := CounterMiddle(filepath.FromSlash("test/missing/counter")); !strings.HasPrefix(errStr(err), "error writing counter file,") {
+		t.Errorf("exp writing err got: '%v'", err)
+	}
+}
@@ -0,0 +1,135 @@
+package revolver
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MultiPolicy specifies when a failing sink fails a MultiWriter write.
+type MultiPolicy int
+
+const (
+	// FailAny fails the write if any sink fails.
+	FailAny MultiPolicy = iota
+	// FailAll fails the write only if all sinks fail.
+	FailAll
+)
+
+// MultiError holds the errors of the failed sinks by sink index.
+type MultiError map[int]error
+
+// Error returns all sink errors ordered by sink index.
+func (m MultiError) Error() string {
+	sinks := make([]int, 0, len(m))
+	for sink := range m {
+		sinks = append(sinks, sink)
+	}
+	sort.Ints(sinks)
+	msgs := make([]string, 0, len(sinks))
+	for _, sink := range sinks {
+		msgs = append(msgs, fmt.Sprintf("sink %d: %v", sink, m[sink]))
+	}
+	return "revolver, multi, " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the sink errors for errors.Is and errors.As.
+func (m MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(m))
+	for _, err := range m {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// MultiWriter writes every write to all of its sinks.
+type MultiWriter struct {
+	sinks  []io.WriteCloser
+	policy MultiPolicy
+	lock   *sync.Mutex // keeps the order of writes equal in all sinks
+
+	// OnError is optional and called with every sink error that does not fail a write due to the policy.
+	// It has to be set before the first write.
+	OnError func(sink int, err error)
+}
+
+// NewMulti returns a MultiWriter with one revolving sink for each of the given confs, e. g.
+// a short retention debug directory and a long retention archive.
+// Every conf is validated like in New, if one sink can not be created the other sinks are closed.
+func NewMulti(policy MultiPolicy, confs ...Conf) (*MultiWriter, error) {
+	if len(confs) == 0 {
+		return nil, fmt.Errorf("revolver, multi, at least one conf is needed")
+	}
+	sinks := make([]io.WriteCloser, 0, len(confs))
+	for index, conf := range confs {
+		sink, err := New(conf)
+		if err != nil {
+			for _, sink := range sinks {
+				sink.Close()
+			}
+			return nil, fmt.Errorf("revolver, multi, sink %d, %w", index, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return NewMultiWriter(policy, sinks...), nil
+}
+
+// NewMultiWriter returns a MultiWriter for the given sinks.
+func NewMultiWriter(policy MultiPolicy, sinks ...io.WriteCloser) *MultiWriter {
+	return &MultiWriter{
+		sinks:  sinks,
+		policy: policy,
+		lock:   &sync.Mutex{},
+	}
+}
+
+// Write writes the given bytes to all sinks.
+// Sink errors are returned as MultiError if they fail the write according to the policy,
+// otherwise they are passed to OnError. If the write fails, n is the lowest count written to a sink.
+func (m *MultiWriter) Write(p []byte) (n int, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	n = len(p)
+	errs := MultiError{}
+	for index, sink := range m.sinks {
+		written, err := sink.Write(p)
+		if err != nil {
+			errs[index] = err
+		}
+		if written < n {
+			n = written
+		}
+	}
+	if len(errs) == 0 {
+		return len(p), nil
+	}
+	if m.policy == FailAll && len(errs) < len(m.sinks) {
+		if m.OnError != nil {
+			for index, err := range errs {
+				m.OnError(index, err)
+			}
+		}
+		return len(p), nil
+	}
+	return n, errs
+}
+
+// Close closes all sinks and returns the errors of all failing sinks as MultiError.
+func (m *MultiWriter) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	errs := MultiError{}
+	for index, sink := range m.sinks {
+		if err := sink.Close(); err != nil {
+			errs[index] = err
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
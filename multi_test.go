@@ -0,0 +1,125 @@
+package revolver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testSink struct {
+	bytes.Buffer
+	err error
+}
+
+func (s *testSink) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.Buffer.Write(p)
+}
+
+func (s *testSink) Close() error {
+	return s.err
+}
+
+func TestMultiWriterWrite(t *testing.T) {
+	failure := errors.New("disk full")
+	var tests = []struct {
+		policy  MultiPolicy
+		errs    []error
+		n       int
+		err     string
+		handled int
+	}{
+		{policy: FailAny, errs: []error{nil, nil}, n: 4},
+		{policy: FailAny, errs: []error{nil, failure}, err: "revolver, multi, sink 1: disk full"},
+		{policy: FailAll, errs: []error{failure, nil}, n: 4, handled: 1},
+		{
+			policy: FailAll,
+			errs:   []error{failure, failure},
+			err:    "revolver, multi, sink 0: disk full; sink 1: disk full",
+		},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. multi write err: %s", index, test.err), func(t *testing.T) {
+			t.Parallel()
+			var sinks []io.WriteCloser
+			for _, err := range test.errs {
+				sinks = append(sinks, &testSink{err: err})
+			}
+			w := NewMultiWriter(test.policy, sinks...)
+			handled := 0
+			w.OnError = func(sink int, err error) {
+				handled++
+			}
+
+			n, err := w.Write([]byte("test"))
+			if errStr(err) != test.err {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			if err != nil && !errors.Is(err, failure) {
+				t.Errorf("%d. exp err to wrap sink error got: %v", index, err)
+			}
+			if n != test.n {
+				t.Errorf("%d. exp n: %d got: %d", index, test.n, n)
+			}
+			if handled != test.handled {
+				t.Errorf("%d. exp handled errors: %d got: %d", index, test.handled, handled)
+			}
+			for sink, err := range test.errs {
+				if got := sinks[sink].(*testSink).String(); err == nil && got != "test" {
+					t.Errorf("%d. exp sink %d content: 'test' got: '%s'", index, sink, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiWriterClose(t *testing.T) {
+	failure := errors.New("closed")
+	w := NewMultiWriter(FailAll, &testSink{}, &testSink{err: failure})
+	err := w.Close()
+	if errStr(err) != "revolver, multi, sink 1: closed" {
+		t.Errorf("exp close err got: '%v'", err)
+	}
+	if err := NewMultiWriter(FailAny, &testSink{}).Close(); err != nil {
+		t.Errorf("exp close to return nil got: '%v'", err)
+	}
+}
+
+func TestNewMulti(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	debug := Conf{Dir: "test/debug", Prefix: "debug_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 1024}
+	archive := Conf{Dir: "test/archive", Prefix: "archive_", Middle: testMiddlePartFunc, MaxFiles: 10, MaxBytes: 1024}
+
+	if _, err := NewMulti(FailAny); errStr(err) != "revolver, multi, at least one conf is needed" {
+		t.Errorf("exp missing conf err got: '%v'", err)
+	}
+	_, err := NewMulti(FailAny, debug, Conf{})
+	if !strings.HasPrefix(errStr(err), "revolver, multi, sink 1, revolver conf can not be empty") {
+		t.Errorf("exp sink err got: '%v'", err)
+	}
+
+	w, err := NewMulti(FailAny, debug, archive)
+	logErr(err, t)
+	_, err = w.Write([]byte("both"))
+	logErr(err, t)
+	logErr(w.Close(), t)
+
+	for _, name := range []string{"test/debug/debug_" + testMiddlePart, "test/archive/archive_" + testMiddlePart} {
+		got, err := ioutil.ReadFile(filepath.FromSlash(name))
+		logErr(err, t)
+		if string(got) != "both" {
+			t.Errorf("exp '%s' content: 'both' got: '%s'", name, got)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package revolver
+
+import "time"
+
+// Option configures the writer returned by NewWriter, see the Conf field of the same name.
+type Option func(conf *Conf)
+
+// NewWriter returns a Writer which writes revolving files as specified by the options.
+// The options are applied in order to DefaultConf and the resulting conf is validated at once.
+// If the configured directory doesn't exist it will be created.
+func NewWriter(options ...Option) (*Writer, error) {
+	conf := DefaultConf()
+	for _, option := range options {
+		option(&conf)
+	}
+	if err := ValidConf(conf); err != nil {
+		return nil, err
+	}
+	return newWriter(clean(conf))
+}
+
+// WithDir sets the dir to write to.
+// CAUTION all files in this dir with the prefix will eventually be deleted.
+func WithDir(dir string) Option {
+	return func(conf *Conf) { conf.Dir = dir }
+}
+
+// WithPrefix sets the prefix which identifies the files of the writer.
+func WithPrefix(prefix string) Option {
+	return func(conf *Conf) { conf.Prefix = prefix }
+}
+
+// WithMiddle sets the function which returns the middle of the file name e. g. a date.
+func WithMiddle(middle func() string) Option {
+	return func(conf *Conf) { conf.Middle = middle }
+}
+
+// WithSuffix sets the suffix every file name ends with.
+func WithSuffix(suffix string) Option {
+	return func(conf *Conf) { conf.Suffix = suffix }
+}
+
+// WithMaxBytes sets the max size of a file.
+func WithMaxBytes(maxBytes int) Option {
+	return func(conf *Conf) { conf.MaxBytes = maxBytes }
+}
+
+// WithRetention sets the max number of files retained, the oldest files are removed on rotation.
+func WithRetention(maxFiles int) Option {
+	return func(conf *Conf) { conf.MaxFiles = maxFiles }
+}
+
+// WithTemplate names the files by the template instead of prefix, middle and suffix.
+func WithTemplate(template string, values map[string]string) Option {
+	return func(conf *Conf) {
+		conf.Template = template
+		conf.Values = values
+	}
+}
+
+// WithPartition places the files in the time partitioned subdirectories of the UTC time layout.
+func WithPartition(layout string) Option {
+	return func(conf *Conf) { conf.Partition = layout }
+}
+
+// WithHeader writes the returned bytes at the start of every file.
+func WithHeader(header func() []byte) Option {
+	return func(conf *Conf) { conf.Header = header }
+}
+
+// WithMetrics reports the write, rotation and removal events to metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(conf *Conf) { conf.Metrics = metrics }
+}
+
+// WithChecksum writes the digest of every closed file into a sidecar file.
+func WithChecksum(mode ChecksumMode) Option {
+	return func(conf *Conf) { conf.Checksum = mode }
+}
+
+// WithEncryption encrypts every file with its own data key.
+func WithEncryption(encryption *Encryption) Option {
+	return func(conf *Conf) { conf.Encryption = encryption }
+}
+
+// WithLimit limits the written bytes per second and the rotations per minute.
+func WithLimit(limit Limit) Option {
+	return func(conf *Conf) { conf.Limit = &limit }
+}
+
+// WithPinFirst keeps the first file of the writer out of the rotation.
+func WithPinFirst() Option {
+	return func(conf *Conf) { conf.PinFirst = true }
+}
+
+// WithPin keeps the files whose base name pin matches out of the rotation.
+func WithPin(pin func(name string) bool) Option {
+	return func(conf *Conf) { conf.Pin = pin }
+}
+
+// WithMaxPinned sets the max number of pinned files retained.
+func WithMaxPinned(maxPinned int) Option {
+	return func(conf *Conf) { conf.MaxPinned = maxPinned }
+}
+
+// WithDedup collapses consecutive identical records into one record and a summary.
+func WithDedup(dedup Dedup) Option {
+	return func(conf *Conf) { conf.Dedup = &dedup }
+}
+
+// WithRedaction adds redactions applied to every record before it is written.
+func WithRedaction(redactions ...Redaction) Option {
+	return func(conf *Conf) { conf.Redact = append(conf.Redact, redactions...) }
+}
+
+// WithIndex keeps the files in memory instead of listing the dir on every rotation.
+// The dir is listed again on the first rotation after resync, 0 never.
+func WithIndex(resync time.Duration) Option {
+	return func(conf *Conf) {
+		conf.Index = true
+		conf.IndexResync = resync
+	}
+}
+
+// WithPreallocate preallocates every file to the max size, Linux only.
+func WithPreallocate() Option {
+	return func(conf *Conf) { conf.Preallocate = true }
+}
+
+// WithStaging appends the suffix to the name of the file while it is written.
+func WithStaging(suffix string) Option {
+	return func(conf *Conf) { conf.Staging = suffix }
+}
+
+// WithRecovery finalises and reports the files left by a previous run.
+func WithRecovery(recovery Recovery) Option {
+	return func(conf *Conf) { conf.Recover = &recovery }
+}
@@ -0,0 +1,79 @@
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestNewWriter(t *testing.T) {
+	var tests = []struct {
+		options []Option
+		err     string
+		files   string
+	}{
+		{
+			options: []Option{WithMaxBytes(8), WithRetention(2)},
+			files:   "[log_2.txt log_3.txt]",
+		},
+		{
+			options: []Option{WithSuffix(""), WithMaxBytes(8), WithRetention(2), WithChecksum(ChecksumFile)},
+			files:   "[log_2 log_2.sha256 log_3 log_3.sha256]",
+		},
+		{
+			options: []Option{WithMaxBytes(8), WithRetention(5), WithPinFirst(), WithMaxPinned(1)},
+			files:   "[log_1.txt log_1.txt.pin log_2.txt log_3.txt]",
+		},
+		{
+			options: []Option{WithMaxBytes(0)},
+			err:     "revolver conf.MaxBytes must be > 0",
+		},
+		{
+			options: []Option{WithRetention(0)},
+			err:     "revolver conf.MaxFiles must be > 0",
+		},
+		{
+			options: []Option{WithPrefix("")},
+			err:     "revolver conf.Prefix can not be empty",
+		},
+		{
+			options: []Option{WithTemplate("{seq}.log", nil), WithPartition("2006")},
+			err:     "revolver conf.Partition can not be combined with conf.Template",
+		},
+		{
+			options: []Option{WithLimit(Limit{Burst: -1})},
+			err:     "revolver conf.Limit.Burst must be >= 0",
+		},
+		{
+			options: []Option{WithStaging("/tmp")},
+			err:     "revolver conf.Staging can not contain path separators",
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. new writer err: %s", index, test.err), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			options := append([]Option{
+				WithDir("test"),
+				WithPrefix("log_"),
+				WithMiddle(testCounterMiddle()),
+			}, test.options...)
+			w, err := NewWriter(options...)
+			if errStr(err) != test.err {
+				t.Fatalf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			for _, record := range []string{"1111", "2222", "3333"} {
+				_, err := w.Write([]byte(record + record))
+				logErrAt(err, index, t)
+			}
+			logErrAt(w.Close(), index, t)
+			if got := fmt.Sprint(dirNames(t)); got != test.files {
+				t.Errorf("%d. exp files: %s got: %s", index, test.files, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pinExt is appended to the file name to get the name of the pin marker.
+const pinExt = ".pin"
+
+// pinning keeps pinned files out of the rotation, pinned files are retained up to their own limit.
+type pinning struct {
+	first   bool // pin the next created file
+	match   func(name string) bool
+	max     int
+	staging string // suffix of the current file, the marker is named after the final name
+}
+
+func newPinning(conf Conf) *pinning {
+	if !conf.PinFirst && conf.Pin == nil {
+		return nil
+	}
+	max := conf.MaxPinned
+	if max == 0 {
+		max = conf.MaxFiles
+	}
+	return &pinning{first: conf.PinFirst, match: conf.Pin, max: max, staging: conf.Staging}
+}
+
+// pinned reports whether the file is matched by the pin predicate or marked as pinned.
+func (p *pinning) pinned(name string) bool {
+	final := name
+	if p.staging != "" {
+		final = strings.TrimSuffix(name, p.staging)
+	}
+	if p.match != nil && p.match(filepath.Base(final)) {
+		return true
+	}
+	_, err := os.Stat(final + pinExt)
+	return err == nil
+}
+
+// retain pins the created file if it is the first one and removes the oldest pinned and rotated
+// files over their limits. The created file counts against the limit of its kind.
+func (p *pinning) retain(dir string, n naming, created string, maxFiles int) (int, error) {
+	if p.first {
+		p.first = false
+		if err := ioutil.WriteFile(strings.TrimSuffix(created, p.staging)+pinExt, nil, 0644); err != nil {
+			return 0, fmt.Errorf("error on pin, %w", err)
+		}
+	}
+	files, err := n.files(dir)
+	if err != nil {
+		return 0, fmt.Errorf("error while counting files, %w", err)
+	}
+	var pinned, rotated []string
+	for _, name := range files {
+		switch {
+		case filepath.Clean(name) == filepath.Clean(created):
+		case p.pinned(name):
+			pinned = append(pinned, name)
+		default:
+			rotated = append(rotated, name)
+		}
+	}
+	keepPinned, keepRotated := p.max, maxFiles-1
+	if p.pinned(created) {
+		keepPinned, keepRotated = p.max-1, maxFiles
+	}
+	removed, err := removeOldest(dir, pinned, keepPinned)
+	forgetRemoved(n, pinned[:removed], err)
+	if err != nil {
+		return removed, err
+	}
+	more, err := removeOldest(dir, rotated, keepRotated)
+	forgetRemoved(n, rotated[:more], err)
+	return removed + more, err
+}
@@ -0,0 +1,84 @@
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPinning(t *testing.T) {
+	var tests = []struct {
+		pinFirst  bool
+		pin       func(name string) bool
+		maxPinned int
+		exp       []string
+	}{
+		{exp: []string{"log_5", "log_6"}},
+		{pinFirst: true, exp: []string{"log_1", "log_4", "log_5", "log_6"}},
+		{pinFirst: true, maxPinned: 1, exp: []string{"log_4", "log_5", "log_6"}},
+		{
+			pin: func(name string) bool { return name == "log_2" },
+			exp: []string{"log_2", "log_5", "log_6"},
+		},
+		{
+			pin:       func(name string) bool { return strings.HasSuffix(name, "2") || strings.HasSuffix(name, "3") },
+			maxPinned: 1,
+			exp:       []string{"log_3", "log_5", "log_6"},
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. pin first: %t max pinned: %d", index, test.pinFirst, test.maxPinned), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			conf := Conf{
+				Dir:       "test",
+				Prefix:    "log_",
+				Middle:    testCounterMiddle(),
+				MaxFiles:  2,
+				MaxBytes:  4,
+				PinFirst:  test.pinFirst,
+				Pin:       test.pin,
+				MaxPinned: test.maxPinned,
+			}
+			for run := 0; run < 2; run++ {
+				w, err := New(conf)
+				logErrAt(err, index, t)
+				for file := 0; file < 3; file++ {
+					_, err := w.Write([]byte("data"))
+					logErrAt(err, index, t)
+				}
+				logErrAt(w.Close(), index, t)
+			}
+
+			files, err := prefixNaming{prefix: "log_"}.files("test")
+			logErrAt(err, index, t)
+			var got []string
+			for _, name := range files {
+				got = append(got, filepath.Base(name))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.exp) {
+				t.Errorf("%d. exp files: %v got: %v", index, test.exp, got)
+			}
+		})
+	}
+}
+
+func TestPinMarkerRemoved(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	conf := Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 4, PinFirst: true, MaxPinned: 1}
+	for run := 0; run < 2; run++ {
+		w, err := New(conf)
+		logErr(err, t)
+		logErr(w.Close(), t)
+	}
+	markers, err := filepath.Glob(filepath.Join("test", "*"+pinExt))
+	logErr(err, t)
+	if len(markers) != 1 {
+		t.Errorf("exp one pin marker got: %v", markers)
+	}
+}
@@ -0,0 +1,117 @@
+package revolver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// sizeMarker starts the trailer of a preallocated file, followed by the written size as uint64.
+var sizeMarker = []byte("RVSIZE01")
+
+const sizeTrailerSize = 16
+
+// errNoFallocate is returned if the file system can't preallocate, the file is written without.
+var errNoFallocate = errors.New("fallocate not supported by the file system")
+
+// mapping writes into a preallocated file through a memory mapping. The trailer after the
+// capacity holds the written size, so a file left preallocated after a crash can be truncated
+// to the written bytes by RecoverPreallocated.
+type mapping struct {
+	file *os.File
+	data []byte
+	size int
+}
+
+// newMapping preallocates the file to capacity and the trailer and maps it into memory.
+func newMapping(file *os.File, capacity int) (*mapping, error) {
+	total := capacity + sizeTrailerSize
+	if err := preallocate(file, int64(total)); err != nil {
+		return nil, fmt.Errorf("error on preallocate, %w", err)
+	}
+	data, err := mmap(file, total)
+	if err != nil {
+		return nil, fmt.Errorf("error on mmap, %w", err)
+	}
+	m := &mapping{file: file, data: data}
+	m.mark()
+	return m, nil
+}
+
+// Write copies p into the mapping and updates the size trailer.
+func (m *mapping) Write(p []byte) (int, error) {
+	if m.size+len(p) > len(m.data)-sizeTrailerSize {
+		return 0, io.ErrShortWrite
+	}
+	copy(m.data[m.size:], p)
+	m.size += len(p)
+	m.mark()
+	return len(p), nil
+}
+
+func (m *mapping) mark() {
+	trailer := m.data[len(m.data)-sizeTrailerSize:]
+	copy(trailer, sizeMarker)
+	binary.BigEndian.PutUint64(trailer[len(sizeMarker):], uint64(m.size))
+}
+
+// close unmaps the file and truncates it to the written size.
+func (m *mapping) close() error {
+	if err := munmap(m.data); err != nil {
+		return fmt.Errorf("error on munmap, %w", err)
+	}
+	m.data = nil
+	return m.file.Truncate(int64(m.size))
+}
+
+// RecoverPreallocated truncates a preallocated file which was not closed, e. g. after a crash,
+// to its written size and reports whether the file was truncated.
+func RecoverPreallocated(name string) (bool, error) {
+	file, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return false, fmt.Errorf("revolver, recover, %w", err)
+	}
+	defer file.Close()
+	size, ok, err := readSizeTrailer(file)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := file.Truncate(size); err != nil {
+		return false, fmt.Errorf("revolver, recover, %w", err)
+	}
+	return true, nil
+}
+
+// readSizeTrailer returns the written size of a preallocated file which was not closed.
+func readSizeTrailer(file *os.File) (int64, bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, false, fmt.Errorf("revolver, recover, %w", err)
+	}
+	if info.Size() < sizeTrailerSize {
+		return 0, false, nil
+	}
+	trailer := make([]byte, sizeTrailerSize)
+	if _, err := file.ReadAt(trailer, info.Size()-sizeTrailerSize); err != nil {
+		return 0, false, fmt.Errorf("revolver, recover, %w", err)
+	}
+	size := binary.BigEndian.Uint64(trailer[len(sizeMarker):])
+	if !bytes.Equal(trailer[:len(sizeMarker)], sizeMarker) || size > uint64(info.Size()-sizeTrailerSize) {
+		return 0, false, nil
+	}
+	return int64(size), true, nil
+}
+
+// isPreallocated reports whether the file is a preallocated file which was not closed.
+func isPreallocated(name string) (bool, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return false, fmt.Errorf("revolver, recover, %w", err)
+	}
+	defer file.Close()
+	_, ok, err := readSizeTrailer(file)
+	return ok, err
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const canPreallocate = true
+
+// preallocate allocates the file blocks with fallocate. File systems without support return
+// errNoFallocate, since a store into a sparse mapped file can't report a full disk.
+func preallocate(file *os.File, size int64) error {
+	err := syscall.Fallocate(int(file.Fd()), 0, 0, size)
+	if err == syscall.EOPNOTSUPP || err == syscall.ENOSYS {
+		return fmt.Errorf("%w, %v", errNoFallocate, err)
+	}
+	return err
+}
+
+func mmap(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
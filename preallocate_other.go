@@ -0,0 +1,23 @@
+//go:build !linux
+
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+const canPreallocate = false
+
+func preallocate(file *os.File, size int64) error {
+	return fmt.Errorf("not supported on %s", runtime.GOOS)
+}
+
+func mmap(file *os.File, size int) ([]byte, error) {
+	return nil, fmt.Errorf("not supported on %s", runtime.GOOS)
+}
+
+func munmap(data []byte) error {
+	return fmt.Errorf("not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,167 @@
+//go:build linux
+
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreallocatedWriter(t *testing.T) {
+	var tests = []struct {
+		encryption bool
+		maxBytes   int
+	}{
+		{encryption: false, maxBytes: 48},
+		{encryption: true, maxBytes: 256},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. encryption: %t", index, test.encryption), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			conf := Conf{
+				Dir:         "test",
+				Prefix:      "log_",
+				Middle:      testCounterMiddle(),
+				MaxFiles:    10,
+				MaxBytes:    test.maxBytes,
+				Header:      func() []byte { return []byte("header\n") },
+				Preallocate: true,
+			}
+			if test.encryption {
+				conf.Encryption = testEncryption(t, 64)
+			}
+			w, err := New(conf)
+			logErrAt(err, index, t)
+			var exp []string
+			for line := 0; line < 20; line++ {
+				record := fmt.Sprintf("line %d\n", line)
+				_, err := w.Write([]byte(record))
+				logErrAt(err, index, t)
+				exp = append(exp, record)
+			}
+			current := w.(*Writer).file.Name()
+			info, err := os.Stat(current)
+			logErrAt(err, index, t)
+			if info.Size() != int64(conf.MaxBytes+sizeTrailerSize) {
+				t.Errorf("%d. exp preallocated size: %d got: %d", index, conf.MaxBytes+sizeTrailerSize, info.Size())
+			}
+			logErrAt(w.Close(), index, t)
+
+			files, err := prefixNaming{prefix: "log_"}.files("test")
+			logErrAt(err, index, t)
+			if len(files) < 2 {
+				t.Errorf("%d. exp rotation got: %v", index, files)
+			}
+			plain := ""
+			for _, name := range files {
+				data, err := ioutil.ReadFile(name)
+				logErrAt(err, index, t)
+				if len(data) > conf.MaxBytes {
+					t.Errorf("%d. exp '%s' size <= %d got: %d", index, name, conf.MaxBytes, len(data))
+				}
+				if test.encryption {
+					r, err := OpenEncrypted(name, conf.Encryption)
+					logErrAt(err, index, t)
+					data, err = ioutil.ReadAll(r)
+					logErrAt(err, index, t)
+					logErrAt(r.Close(), index, t)
+				}
+				plain += strings.TrimPrefix(string(data), "header\n")
+			}
+			if plain != strings.Join(exp, "") {
+				t.Errorf("%d. exp content: %q got: %q", index, strings.Join(exp, ""), plain)
+			}
+		})
+	}
+}
+
+func TestRecoverPreallocated(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	logErr(os.Mkdir("test", 0755), t)
+	name := filepath.Join("test", "log_crashed")
+	file, err := os.Create(name)
+	logErr(err, t)
+	m, err := newMapping(file, 64)
+	logErr(err, t)
+	_, err = m.Write([]byte("before crash\n"))
+	logErr(err, t)
+	logErr(munmap(m.data), t) // crash without truncate
+	logErr(file.Close(), t)
+
+	plain := filepath.Join("test", "log_plain")
+	logErr(ioutil.WriteFile(plain, []byte("no trailer, just some plain text"), 0644), t)
+
+	w, err := New(Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 10, MaxBytes: 64, Preallocate: true})
+	logErr(err, t)
+	logErr(w.Close(), t)
+	for file, exp := range map[string]string{name: "before crash\n", plain: "no trailer, just some plain text"} {
+		data, err := ioutil.ReadFile(file)
+		logErr(err, t)
+		if string(data) != exp {
+			t.Errorf("exp '%s' recovered: %q got: %q", file, exp, data)
+		}
+	}
+	recovered, err := RecoverPreallocated(name)
+	logErr(err, t)
+	if recovered {
+		t.Errorf("exp recovered file not to be recovered again")
+	}
+	if _, err := RecoverPreallocated(filepath.Join("test", "missing")); !strings.HasPrefix(errStr(err), "revolver, recover, ") {
+		t.Errorf("exp recover err got: %v", err)
+	}
+}
+
+func TestPreallocatedUpdateConfig(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := NewWriter(WithDir("test"), WithPrefix("log_"), WithSuffix(""), WithPreallocate(),
+		WithMiddle(testCounterMiddle()), WithMaxBytes(8))
+	logErr(err, t)
+	_, err = w.Write([]byte("1111"))
+	logErr(err, t)
+	logErr(w.UpdateConfig(WithMaxBytes(16)), t)
+	_, err = w.Write([]byte("22222222"))
+	logErr(err, t)
+	info, err := os.Stat(filepath.Join("test", "log_2"))
+	logErr(err, t)
+	if info.Size() != int64(16+sizeTrailerSize) {
+		t.Errorf("exp preallocated size: %d got: %d", 16+sizeTrailerSize, info.Size())
+	}
+	logErr(w.Close(), t)
+	if got := readAll(t); got != "111122222222" {
+		t.Errorf("exp content: %q got: %q", "111122222222", got)
+	}
+}
+
+func TestPreallocatedDedupClose(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	metrics := NewMetrics("app")
+	w, err := NewWriter(WithDir("test"), WithPrefix("log_"), WithSuffix(""), WithPreallocate(),
+		WithMiddle(testCounterMiddle()), WithMaxBytes(8), WithDedup(Dedup{}), WithMetrics(metrics))
+	logErr(err, t)
+	for write := 0; write < 5; write++ {
+		_, err := w.Write([]byte("1234567\n"))
+		logErr(err, t)
+	}
+	logErr(w.Close(), t)
+	if got := fmt.Sprint(dirNames(t)); got != "[log_1]" {
+		t.Errorf("exp no file created on close got: %s", got)
+	}
+	if got := readAll(t); got != "1234567\n" {
+		t.Errorf("exp content: %q got: %q", "1234567\n", got)
+	}
+	if got := metrics.WriteErrors(); got != 1 {
+		t.Errorf("exp the dropped summary as write error got: %d", got)
+	}
+}
@@ -0,0 +1,107 @@
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Recovery specifies how New handles files left by a previous run which did not close its file,
+// e. g. after a crash. Such files are staged files, preallocated files which were not truncated
+// and, with checksums, the newest file without a sidecar.
+type Recovery struct {
+	// Finalise appends the footer, publishes and checksums the recovered files, else they are only
+	// reported. Preallocated files are always truncated.
+	Finalise bool
+	// Footer is optional and appended to every finalised file, e. g. a note about the crash.
+	// Encrypted files get no footer.
+	Footer func(name string) []byte
+	// Report is optional and called for every recovered file, oldest first.
+	Report func(file RecoveredFile)
+}
+
+// RecoveredFile is a file left by a previous run.
+type RecoveredFile struct {
+	Name         string // final name if the file was published
+	Staged       bool   // was left under its staging name
+	Preallocated bool   // was left preallocated
+	Unchecked    bool   // was left without checksum
+	Finalised    bool
+}
+
+// recover finds and finalises the files left by a previous run before the first file is created.
+// Preallocated files are always truncated, with or without recovery.
+func (l *Writer) recover(recovery *Recovery) error {
+	if recovery == nil && !l.preallocate {
+		return nil
+	}
+	files, err := l.naming.files(l.dir)
+	if err != nil {
+		return fmt.Errorf("revolver, recover, %w", err)
+	}
+	for index, name := range files {
+		file := RecoveredFile{Name: name}
+		file.Staged = l.staging != "" && strings.HasSuffix(name, l.staging)
+		if l.preallocate {
+			if file.Preallocated, err = isPreallocated(name); err != nil {
+				return err
+			}
+		}
+		if l.checksum != ChecksumNone && index == len(files)-1 {
+			_, err := os.Stat(strings.TrimSuffix(name, l.staging) + checksumExt)
+			file.Unchecked = os.IsNotExist(err)
+		}
+		if !file.Staged && !file.Preallocated && !file.Unchecked {
+			continue
+		}
+		if file.Preallocated {
+			if _, err := RecoverPreallocated(name); err != nil {
+				return err
+			}
+		}
+		if recovery == nil {
+			continue
+		}
+		if recovery.Finalise {
+			if err := l.finalise(&file, recovery); err != nil {
+				return fmt.Errorf("revolver, recover, %s, %w", name, err)
+			}
+		}
+		if recovery.Report != nil {
+			recovery.Report(file)
+		}
+	}
+	return nil
+}
+
+func (l *Writer) finalise(file *RecoveredFile, recovery *Recovery) error {
+	if recovery.Footer != nil && l.encryption == nil {
+		out, err := os.OpenFile(file.Name, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(recovery.Footer(file.Name))
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if file.Staged {
+		name, err := l.publish(file.Name)
+		if err != nil {
+			return err
+		}
+		file.Name = name
+	}
+	if l.checksum != ChecksumNone {
+		if _, err := os.Stat(file.Name + checksumExt); os.IsNotExist(err) {
+			if err := l.writeChecksum(file.Name); err != nil {
+				return err
+			}
+		}
+	}
+	file.Finalised = true
+	return nil
+}
@@ -0,0 +1,109 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// crash leaves the current file of the writer as if the process died.
+func crash(t *testing.T, w *Writer) {
+	if w.mapping != nil {
+		logErr(munmap(w.mapping.data), t)
+	}
+	logErr(w.file.Close(), t)
+}
+
+func TestRecovery(t *testing.T) {
+	var tests = []struct {
+		preallocate bool
+		finalise    bool
+		exp         RecoveredFile
+		files       []string
+		content     string
+	}{
+		{
+			finalise: true,
+			exp:      RecoveredFile{Name: "log_2.txt", Staged: true, Unchecked: true, Finalised: true},
+			files:    []string{"log_1.txt", "log_1.txt.sha256", "log_2.txt", "log_2.txt.sha256", "log_3.txt", "log_3.txt.sha256"},
+			content:  "1111crashed log_2.txt.inprogress\n",
+		},
+		{
+			finalise: false,
+			exp:      RecoveredFile{Name: "log_2.txt.inprogress", Staged: true, Unchecked: true},
+			files:    []string{"log_1.txt", "log_1.txt.sha256", "log_2.txt.inprogress", "log_3.txt", "log_3.txt.sha256"},
+			content:  "1111",
+		},
+		{
+			preallocate: true,
+			finalise:    true,
+			exp:         RecoveredFile{Name: "log_2.txt", Staged: true, Preallocated: true, Unchecked: true, Finalised: true},
+			files:       []string{"log_1.txt", "log_1.txt.sha256", "log_2.txt", "log_2.txt.sha256", "log_3.txt", "log_3.txt.sha256"},
+			content:     "1111crashed log_2.txt.inprogress\n",
+		},
+		{
+			preallocate: true,
+			finalise:    false,
+			exp:         RecoveredFile{Name: "log_2.txt.inprogress", Staged: true, Preallocated: true, Unchecked: true},
+			files:       []string{"log_1.txt", "log_1.txt.sha256", "log_2.txt.inprogress", "log_3.txt", "log_3.txt.sha256"},
+			content:     "1111",
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. preallocate: %t finalise: %t", index, test.preallocate, test.finalise), func(t *testing.T) {
+			if test.preallocate && runtime.GOOS != "linux" {
+				t.Skip("preallocation is linux only")
+			}
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			conf := Conf{
+				Dir:         "test",
+				Prefix:      "log_",
+				Suffix:      ".txt",
+				Middle:      testCounterMiddle(),
+				MaxFiles:    10,
+				MaxBytes:    8,
+				Checksum:    ChecksumChain,
+				Staging:     ".inprogress",
+				Preallocate: test.preallocate,
+			}
+			w, err := New(conf)
+			logErrAt(err, index, t)
+			for _, p := range []string{"00000000", "1111"} {
+				_, err := w.Write([]byte(p))
+				logErrAt(err, index, t)
+			}
+			crash(t, w.(*Writer))
+
+			var reported []RecoveredFile
+			conf.Recover = &Recovery{
+				Finalise: test.finalise,
+				Footer:   func(name string) []byte { return []byte("crashed " + filepath.Base(name) + "\n") },
+				Report:   func(file RecoveredFile) { reported = append(reported, file) },
+			}
+			w, err = New(conf)
+			logErrAt(err, index, t)
+			logErrAt(w.Close(), index, t)
+
+			test.exp.Name = filepath.Join("test", test.exp.Name)
+			if len(reported) != 1 || reported[0] != test.exp {
+				t.Errorf("%d. exp reported: %+v got: %+v", index, test.exp, reported)
+			}
+			if got := dirNames(t); fmt.Sprint(got) != fmt.Sprint(test.files) {
+				t.Errorf("%d. exp files: %v got: %v", index, test.files, got)
+			}
+			data, err := ioutil.ReadFile(test.exp.Name)
+			logErrAt(err, index, t)
+			if string(data) != test.content {
+				t.Errorf("%d. exp content: %q got: %q", index, test.content, data)
+			}
+			if test.finalise {
+				logErrAt(Verify("test", "log_"), index, t)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package revolver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// DefaultRedaction replaces the redacted parts of a record without a Replacement.
+const DefaultRedaction = "[REDACTED]"
+
+// Redaction is a rule which redacts parts of every record before it is written.
+// Either Pattern or KeyPath must be set.
+type Redaction struct {
+	// Pattern redacts all matches within the record.
+	Pattern *regexp.Regexp
+	// KeyPath redacts the value of the dot separated key path, e. g. "user.email", in JSON object records.
+	// Keys within arrays are matched with the path of the array.
+	KeyPath string
+	// Replacement is optional and replaces the redacted parts, defaults to DefaultRedaction.
+	Replacement string
+}
+
+// Common redaction patterns, they match the value only without surrounding key or text.
+// Matches of CardNumberPattern are only redacted if they pass the Luhn check, yet about one in
+// ten other numbers of 13 to 19 digits, e. g. millisecond timestamps or IDs, pass it as well.
+var (
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	CardNumberPattern = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	BearerPattern     = regexp.MustCompile(`Bearer [A-Za-z0-9\-._~+/]+=*`)
+)
+
+func validRedactions(redactions []Redaction) error {
+	for index, r := range redactions {
+		if (r.Pattern == nil) == (r.KeyPath == "") {
+			return invalidConf(fmt.Sprintf("Redact[%d]", index), "needs either a Pattern or a KeyPath")
+		}
+	}
+	return nil
+}
+
+// redactor applies the redactions to every record.
+type redactor struct {
+	patterns []Redaction
+	paths    map[string]string // replacement of every key path
+}
+
+func newRedactor(redactions []Redaction) *redactor {
+	if len(redactions) == 0 {
+		return nil
+	}
+	r := &redactor{paths: map[string]string{}}
+	for _, redaction := range redactions {
+		if redaction.Replacement == "" {
+			redaction.Replacement = DefaultRedaction
+		}
+		if redaction.Pattern != nil {
+			r.patterns = append(r.patterns, redaction)
+			continue
+		}
+		r.paths[redaction.KeyPath] = redaction.Replacement
+	}
+	return r
+}
+
+// apply returns the redacted record and the number of redactions.
+// The given record is returned unchanged if nothing was redacted.
+func (r *redactor) apply(record []byte) ([]byte, int) {
+	count := 0
+	if len(r.paths) > 0 {
+		if redacted, n := r.redactJSON(record); n > 0 {
+			record, count = redacted, n
+		}
+	}
+	for _, redaction := range r.patterns {
+		n := 0
+		redacted := redaction.Pattern.ReplaceAllFunc(record, func(match []byte) []byte {
+			if redaction.Pattern == CardNumberPattern && !luhn(match) {
+				return match
+			}
+			n++
+			return []byte(redaction.Replacement)
+		})
+		if n > 0 {
+			record, count = redacted, count+n
+		}
+	}
+	return record, count
+}
+
+// luhn reports whether the digits of the number pass the Luhn check, other characters are ignored.
+func luhn(number []byte) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+		digit := int(number[i] - '0')
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// redactJSON returns the JSON record with the values of the matching key paths replaced.
+// A record may hold several top-level values, e. g. newline delimited objects. All other bytes
+// are kept as they are. If the record starts as JSON but is invalid from some value on, the rest
+// of the record up to the trailing newline is redacted with DefaultRedaction.
+func (r *redactor) redactJSON(record []byte) ([]byte, int) {
+	if trimmed := bytes.TrimLeft(record, " \t"); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, 0
+	}
+	j := &jsonRedaction{redactor: r, record: record, dec: json.NewDecoder(bytes.NewReader(record))}
+	j.dec.UseNumber()
+	for {
+		start := j.valueStart(j.dec.InputOffset())
+		if start == len(record) {
+			break
+		}
+		valid := len(j.spans)
+		if err := j.value(""); err != nil {
+			end := len(bytes.TrimRight(record, "\r\n"))
+			if end < start {
+				end = start
+			}
+			remainder := span{start: start, end: end, replacement: []byte(DefaultRedaction)}
+			j.spans = append(j.spans[:valid], remainder)
+			break
+		}
+	}
+	if len(j.spans) == 0 {
+		return nil, 0
+	}
+	out := make([]byte, 0, len(record))
+	last := 0
+	for _, s := range j.spans {
+		out = append(out, record[last:s.start]...)
+		out = append(out, s.replacement...)
+		last = s.end
+	}
+	return append(out, record[last:]...), len(j.spans)
+}
+
+// span is a redacted part of a record.
+type span struct {
+	start, end  int
+	replacement []byte
+}
+
+type jsonRedaction struct {
+	*redactor
+	record []byte
+	dec    *json.Decoder
+	spans  []span // in order of the record
+}
+
+// valueStart returns the offset of the next value after whitespace and a colon.
+func (j *jsonRedaction) valueStart(offset int64) int {
+	start := int(offset)
+	for start < len(j.record) && bytes.IndexByte([]byte(" \t\r\n:"), j.record[start]) >= 0 {
+		start++
+	}
+	return start
+}
+
+// value walks the next value at the given path and records the matching members.
+func (j *jsonRedaction) value(path string) error {
+	tok, err := j.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for j.dec.More() {
+			key, err := j.dec.Token()
+			if err != nil {
+				return err
+			}
+			child := key.(string)
+			if path != "" {
+				child = path + "." + child
+			}
+			if replace, ok := j.paths[child]; ok {
+				start := j.valueStart(j.dec.InputOffset())
+				if err := j.skip(); err != nil {
+					return err
+				}
+				j.spans = append(j.spans, span{start: start, end: int(j.dec.InputOffset()), replacement: quote(replace)})
+				continue
+			}
+			if err := j.value(child); err != nil {
+				return err
+			}
+		}
+		_, err = j.dec.Token()
+		return err
+	case json.Delim('['):
+		for j.dec.More() {
+			if err := j.value(path); err != nil {
+				return err
+			}
+		}
+		_, err = j.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// skip consumes the next value.
+func (j *jsonRedaction) skip() error {
+	depth := 0
+	for {
+		tok, err := j.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// quote returns the replacement as JSON string without escaping HTML characters.
+func quote(replacement string) []byte {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(replacement)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
@@ -0,0 +1,155 @@
+package revolver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	var tests = []struct {
+		redactions []Redaction
+		record     string
+		exp        string
+		count      int
+	}{
+		{
+			redactions: []Redaction{{Pattern: EmailPattern}},
+			record:     "mail from jane.doe@example.com to john@example.org\n",
+			exp:        "mail from [REDACTED] to [REDACTED]\n",
+			count:      2,
+		},
+		{
+			redactions: []Redaction{{Pattern: CardNumberPattern, Replacement: "****"}},
+			record:     "paid with 4111 1111 1111 1111 order 12345\n",
+			exp:        "paid with **** order 12345\n",
+			count:      1,
+		},
+		{
+			redactions: []Redaction{{Pattern: CardNumberPattern}},
+			record:     `{"ts":1760000000000,"card":"4111-1111-1111-1111"}`,
+			exp:        `{"ts":1760000000000,"card":"[REDACTED]"}`,
+			count:      1,
+		},
+		{
+			redactions: []Redaction{{Pattern: BearerPattern}},
+			record:     "Authorization: Bearer abc.DEF-123=\n",
+			exp:        "Authorization: [REDACTED]\n",
+			count:      1,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}, {KeyPath: "user.email"}},
+			record:     `{"time":1,"token":"secret","user":{"id":7,"email":"a@b.cd"},"n":1.50}` + "\n",
+			exp:        `{"time":1,"token":"[REDACTED]","user":{"id":7,"email":"[REDACTED]"},"n":1.50}` + "\n",
+			count:      2,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "items.secret"}},
+			record:     `{"items":[{"secret":{"a":[1,2]}},{"other":true},null]}`,
+			exp:        `{"items":[{"secret":"[REDACTED]"},{"other":true},null]}`,
+			count:      1,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}},
+			record:     `{"user":{"token":"nested"}}` + "\n",
+			exp:        `{"user":{"token":"nested"}}` + "\n",
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}},
+			record:     "token=plain\n",
+			exp:        "token=plain\n",
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}},
+			record:     `{"token":"broken"` + "\n",
+			exp:        "[REDACTED]\n",
+			count:      1,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}},
+			record:     `{"token":"x"}` + "\n" + `{"token":"secret2"}` + "\n",
+			exp:        `{"token":"[REDACTED]"}` + "\n" + `{"token":"[REDACTED]"}` + "\n",
+			count:      2,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}},
+			record:     `{"token":"x"} token=secret` + "\n",
+			exp:        `{"token":"[REDACTED]"} [REDACTED]` + "\n",
+			count:      2,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token", Replacement: "<hidden>"}},
+			record:     "{ \"msg\": \"a<b \xff\", \"token\" : \"x\" }\n",
+			exp:        "{ \"msg\": \"a<b \xff\", \"token\" : \"<hidden>\" }\n",
+			count:      1,
+		},
+		{
+			redactions: []Redaction{{KeyPath: "token"}, {Pattern: regexp.MustCompile(`id=\d+`)}},
+			record:     `{"token":"x","msg":"id=42"}`,
+			exp:        `{"token":"[REDACTED]","msg":"[REDACTED]"}`,
+			count:      2,
+		},
+	}
+	for index, test := range tests {
+		got, count := newRedactor(test.redactions).apply([]byte(test.record))
+		if string(got) != test.exp || count != test.count {
+			t.Errorf("%d. exp: '%s' count: %d got: '%s' count: %d", index, test.exp, test.count, got, count)
+		}
+	}
+}
+
+func TestRedactedWriter(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	metrics := NewMetrics("app")
+	w, err := New(Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   testMiddlePartFunc,
+		MaxFiles: 1,
+		MaxBytes: 20,
+		Metrics:  metrics,
+		Redact:   []Redaction{{Pattern: regexp.MustCompile(`secret-\w+`), Replacement: "***"}},
+	})
+	logErr(err, t)
+	record := "key secret-0123456789abcdef\n" // over MaxBytes before the redaction
+	n, err := w.Write([]byte(record))
+	logErr(err, t)
+	if n != len(record) {
+		t.Errorf("exp n: %d got: %d", len(record), n)
+	}
+	logErr(w.Close(), t)
+
+	data, err := ioutil.ReadFile(filepath.Join("test", "log_"+testMiddlePart))
+	logErr(err, t)
+	if exp := "key ***\n"; string(data) != exp {
+		t.Errorf("exp content: '%s' got: '%s'", exp, data)
+	}
+	if metrics.Redactions() != 1 || metrics.BytesWritten() != 8 {
+		t.Errorf("exp 1 redaction and 8 bytes got: %d and %d", metrics.Redactions(), metrics.BytesWritten())
+	}
+}
+
+func TestValidRedactions(t *testing.T) {
+	var tests = []struct {
+		redactions []Redaction
+		err        string
+	}{
+		{redactions: []Redaction{{Pattern: EmailPattern}, {KeyPath: "a.b"}}},
+		{redactions: []Redaction{{}}, err: "revolver conf.Redact[0] needs either a Pattern or a KeyPath"},
+		{
+			redactions: []Redaction{{KeyPath: "a"}, {Pattern: EmailPattern, KeyPath: "b"}},
+			err:        "revolver conf.Redact[1] needs either a Pattern or a KeyPath",
+		},
+	}
+	for index, test := range tests {
+		conf := DefaultConf()
+		conf.Redact = test.redactions
+		if err := ValidConf(conf); errStr(err) != test.err {
+			t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+		}
+	}
+}
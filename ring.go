@@ -0,0 +1,152 @@
+package revolver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const defaultIncidentPrefix = "incident-"
+
+// Ring specifies the in memory history of a RingWriter.
+// Records are dropped as a whole, a single record over Size keeps its last Size bytes.
+type Ring struct {
+	Size     int            // bytes of the history, the oldest records are dropped first
+	MinLevel slog.Level     // records below MinLevel are only kept in the history
+	Classify Classifier     // optional, defaults to the classifier of NewLevelRouter
+	Trigger  *regexp.Regexp // optional, every record matching Trigger flushes the history
+
+	IncidentDir    string // optional, defaults to the Dir of the revolving files
+	IncidentPrefix string // optional, defaults to "incident-"
+}
+
+// RingWriter keeps the recent records of all levels in memory and writes the records
+// with at least MinLevel into revolving files. Flush dumps the history into a new incident file,
+// which is never rotated. Every Write is treated as one record.
+type RingWriter struct {
+	out      io.WriteCloser
+	classify Classifier
+	minLevel slog.Level
+	trigger  *regexp.Regexp
+	dir      string
+	prefix   string
+
+	records [][]byte // history, oldest first
+	size    int      // bytes of the history
+	max     int
+	lock    *sync.Mutex
+}
+
+// NewRing returns a RingWriter which writes the revolving files specified by conf.
+func NewRing(conf Conf, ring Ring) (*RingWriter, error) {
+	if ring.Size < 1 {
+		return nil, invalidConf("Ring.Size", "must be > 0")
+	}
+	if ring.Classify == nil {
+		ring.Classify = defaultClassifier
+	}
+	if ring.IncidentDir == "" {
+		ring.IncidentDir = conf.Dir
+	}
+	if ring.IncidentPrefix == "" {
+		ring.IncidentPrefix = defaultIncidentPrefix
+	}
+	if conf.Template == "" && filepath.Clean(ring.IncidentDir) == filepath.Clean(conf.Dir) &&
+		strings.HasPrefix(ring.IncidentPrefix, conf.Prefix) {
+		return nil, invalidConf("Ring.IncidentPrefix", "'%s' would be rotated as prefix '%s'",
+			ring.IncidentPrefix, conf.Prefix)
+	}
+	out, err := New(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &RingWriter{
+		out:      out,
+		classify: ring.Classify,
+		minLevel: ring.MinLevel,
+		trigger:  ring.Trigger,
+		dir:      ring.IncidentDir,
+		prefix:   ring.IncidentPrefix,
+		max:      ring.Size,
+		lock:     &sync.Mutex{},
+	}, nil
+}
+
+// Write keeps the record in the history and writes it into the revolving files if its level is
+// at least MinLevel. A record matching the trigger flushes the history including the record.
+func (r *RingWriter) Write(p []byte) (n int, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.keep(p)
+	n = len(p)
+	level, ok := r.classify(p)
+	if !ok {
+		level = slog.LevelInfo
+	}
+	if level >= r.minLevel {
+		n, err = r.out.Write(p)
+	}
+	if r.trigger != nil && r.trigger.Match(p) {
+		if _, flushErr := r.flush(); err == nil && flushErr != nil {
+			err = flushErr
+		}
+	}
+	return n, err
+}
+
+// keep appends p to the history and drops the oldest records over the history size.
+func (r *RingWriter) keep(p []byte) {
+	if len(p) > r.max {
+		p = p[len(p)-r.max:]
+	}
+	r.records = append(r.records, append([]byte{}, p...))
+	r.size += len(p)
+	for r.size > r.max {
+		r.size -= len(r.records[0])
+		r.records = r.records[1:]
+	}
+}
+
+// history returns the kept records.
+func (r *RingWriter) history() []byte {
+	return bytes.Join(r.records, nil)
+}
+
+// Flush writes the history into a new incident file, clears it and returns the file name.
+func (r *RingWriter) Flush() (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.flush()
+}
+
+func (r *RingWriter) flush() (string, error) {
+	if err := setupDirs(r.dir); err != nil {
+		return "", fmt.Errorf("revolver, incident, %w", err)
+	}
+	file, err := createFile(r.dir, r.prefix, ".txt", UTCMiddle)
+	if err != nil {
+		return "", fmt.Errorf("revolver, incident, %w", err)
+	}
+	_, err = file.Write(r.history())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return file.Name(), fmt.Errorf("revolver, incident, %w", err)
+	}
+	r.records, r.size = nil, 0
+	return file.Name(), nil
+}
+
+// Close closes the revolving writer, the history is dropped.
+func (r *RingWriter) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.out.Close()
+}
@@ -0,0 +1,99 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRingWriter(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	conf := Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 1000}
+	r, err := NewRing(conf, Ring{Size: 64, MinLevel: 0, Trigger: regexp.MustCompile("panic")})
+	logErr(err, t)
+	records := []string{
+		"DEBUG: one\n",
+		"INFO: two\n",
+		"DEBUG: three\n",
+		"ERROR: panic\n",
+		"DEBUG: four\n",
+	}
+	for _, record := range records {
+		n, err := r.Write([]byte(record))
+		logErr(err, t)
+		if n != len(record) {
+			t.Errorf("exp n: %d got: %d", len(record), n)
+		}
+	}
+	name, err := r.Flush()
+	logErr(err, t)
+	logErr(r.Close(), t)
+
+	data, err := ioutil.ReadFile(filepath.Join("test", "log_"+testMiddlePart))
+	logErr(err, t)
+	if exp := "INFO: two\nERROR: panic\n"; string(data) != exp {
+		t.Errorf("exp revolving file: '%s' got: '%s'", exp, data)
+	}
+	incidents, err := filepath.Glob(filepath.Join("test", defaultIncidentPrefix+"*.txt"))
+	logErr(err, t)
+	if len(incidents) != 2 {
+		t.Fatalf("exp 2 incident files got: %v", incidents)
+	}
+	var got []string
+	for _, incident := range incidents {
+		data, err := ioutil.ReadFile(incident)
+		logErr(err, t)
+		got = append(got, string(data))
+	}
+	exp := []string{strings.Join(records[:4], ""), "DEBUG: four\n"}
+	if incidents[1] != name || fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Errorf("exp incidents: %q got: %q", exp, got)
+	}
+}
+
+func TestRingHistory(t *testing.T) {
+	var tests = []struct {
+		size   int
+		writes []string
+		exp    string
+	}{
+		{size: 10, writes: []string{"a\n", "b\n"}, exp: "a\nb\n"},
+		{size: 6, writes: []string{"aa\n", "bb\n"}, exp: "aa\nbb\n"},
+		{size: 6, writes: []string{"aa\n", "bb\n", "ccc\n"}, exp: "ccc\n"},
+		{size: 6, writes: []string{"a\n", "bb\n", "c\n"}, exp: "bb\nc\n"},
+		{size: 4, writes: []string{"aa\n", "bbbbbb\n"}, exp: "bbb\n"},
+		{size: 4, writes: []string{"aaaaaaaaa"}, exp: "aaaa"},
+	}
+	for index, test := range tests {
+		r := &RingWriter{max: test.size}
+		for _, write := range test.writes {
+			r.keep([]byte(write))
+		}
+		if got := string(r.history()); got != test.exp {
+			t.Errorf("%d. exp history: %q got: %q", index, test.exp, got)
+		}
+	}
+}
+
+func TestNewRingErr(t *testing.T) {
+	var tests = []struct {
+		ring Ring
+		err  string
+	}{
+		{ring: Ring{}, err: "revolver conf.Ring.Size must be > 0"},
+		{ring: Ring{Size: 1, IncidentPrefix: "log_incident"}, err: "revolver conf.Ring.IncidentPrefix 'log_incident' would be rotated as prefix 'log_'"},
+		{ring: Ring{Size: 1}, err: "revolver conf.MaxFiles must be > 0"},
+	}
+	for index, test := range tests {
+		conf := Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxBytes: 10}
+		if _, err := NewRing(conf, test.ring); errStr(err) != test.err {
+			t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package revolver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+)
+
+// Classifier returns the level of the given record and whether a level was found.
+type Classifier func(record []byte) (slog.Level, bool)
+
+// Route is a revolving stream for all records with at least MinLevel.
+type Route struct {
+	MinLevel slog.Level
+	Conf     Conf
+}
+
+// LevelRouter writes every record to the route with the highest MinLevel not above the record level.
+// Records below all MinLevels are written to the route with the lowest MinLevel.
+// Every Write is treated as one record, as written by log.Logger and slog handlers.
+type LevelRouter struct {
+	classify Classifier
+	routes   []Route // ordered by MinLevel, highest first
+	sinks    []io.WriteCloser
+}
+
+// NewLevelRouter returns a LevelRouter with one revolving writer for each of the given routes.
+// Records without a level found by classify are treated as slog.LevelInfo.
+// If classify is nil SlogClassifier is used and PrefixClassifier if that finds no level.
+func NewLevelRouter(classify Classifier, routes ...Route) (*LevelRouter, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("revolver, router, at least one route is needed")
+	}
+	if classify == nil {
+		classify = defaultClassifier
+	}
+	ordered := append([]Route{}, routes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].MinLevel > ordered[j].MinLevel
+	})
+	for index := 1; index < len(ordered); index++ {
+		if ordered[index].MinLevel == ordered[index-1].MinLevel {
+			return nil, fmt.Errorf("revolver, router, duplicate route for level %v", ordered[index].MinLevel)
+		}
+	}
+
+	router := &LevelRouter{classify: classify, routes: ordered}
+	for _, route := range ordered {
+		sink, err := New(route.Conf)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("revolver, router, level %v, %w", route.MinLevel, err)
+		}
+		router.sinks = append(router.sinks, sink)
+	}
+	return router, nil
+}
+
+// Write classifies the given record and writes it to the matching route.
+func (r *LevelRouter) Write(p []byte) (n int, err error) {
+	level, ok := r.classify(p)
+	if !ok {
+		level = slog.LevelInfo
+	}
+	return r.sinks[r.route(level)].Write(p)
+}
+
+func (r *LevelRouter) route(level slog.Level) int {
+	for index, route := range r.routes {
+		if level >= route.MinLevel {
+			return index
+		}
+	}
+	return len(r.routes) - 1
+}
+
+// Close closes all route writers and returns the errors of all failing routes as MultiError.
+func (r *LevelRouter) Close() error {
+	errs := MultiError{}
+	for index, sink := range r.sinks {
+		if err := sink.Close(); err != nil {
+			errs[index] = err
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+var (
+	slogTextLevel = []byte("level=")
+	slogJSONLevel = []byte(`"level":"`)
+)
+
+// SlogClassifier finds the level of records written by slog.TextHandler and slog.JSONHandler
+// e. g. level=WARN or "level":"ERROR+2".
+func SlogClassifier(record []byte) (slog.Level, bool) {
+	var val []byte
+	if start := bytes.Index(record, slogJSONLevel); start >= 0 {
+		val = record[start+len(slogJSONLevel):]
+		if end := bytes.IndexByte(val, '"'); end >= 0 {
+			val = val[:end]
+		}
+	} else if start := bytes.Index(record, slogTextLevel); start >= 0 {
+		val = record[start+len(slogTextLevel):]
+		if end := bytes.IndexAny(val, " \n"); end >= 0 {
+			val = val[:end]
+		}
+	} else {
+		return 0, false
+	}
+	var level slog.Level
+	if err := level.UnmarshalText(val); err != nil {
+		return 0, false
+	}
+	return level, true
+}
+
+var prefixLevels = []struct {
+	prefix []byte
+	level  slog.Level
+}{
+	{prefix: []byte("DEBUG:"), level: slog.LevelDebug},
+	{prefix: []byte("INFO:"), level: slog.LevelInfo},
+	{prefix: []byte("WARN:"), level: slog.LevelWarn},
+	{prefix: []byte("WARNING:"), level: slog.LevelWarn},
+	{prefix: []byte("ERROR:"), level: slog.LevelError},
+	{prefix: []byte("FATAL:"), level: slog.LevelError + 4},
+	{prefix: []byte("PANIC:"), level: slog.LevelError + 4},
+}
+
+// PrefixClassifier finds the first common level prefix e. g. "ERROR:" or "WARN:"
+// as set with log.SetPrefix or written with log.Printf("ERROR: ...").
+func PrefixClassifier(record []byte) (slog.Level, bool) {
+	first := -1
+	var level slog.Level
+	for _, candidate := range prefixLevels {
+		index := bytes.Index(record, candidate.prefix)
+		if index >= 0 && (first < 0 || index < first) {
+			first = index
+			level = candidate.level
+		}
+	}
+	return level, first >= 0
+}
+
+func defaultClassifier(record []byte) (slog.Level, bool) {
+	if level, ok := SlogClassifier(record); ok {
+		return level, true
+	}
+	return PrefixClassifier(record)
+}
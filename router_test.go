@@ -0,0 +1,112 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifiers(t *testing.T) {
+	var tests = []struct {
+		classify Classifier
+		record   string
+		level    slog.Level
+		ok       bool
+	}{
+		{classify: SlogClassifier, record: `time=now level=WARN msg="disk slow"`, level: slog.LevelWarn, ok: true},
+		{classify: SlogClassifier, record: `{"time":"now","level":"ERROR+2","msg":"down"}`, level: slog.LevelError + 2, ok: true},
+		{classify: SlogClassifier, record: "level=LOUD msg=x", ok: false},
+		{classify: SlogClassifier, record: "ERROR: no slog", ok: false},
+		{classify: PrefixClassifier, record: "2026/10/17 13:45:01 ERROR: down", level: slog.LevelError, ok: true},
+		{classify: PrefixClassifier, record: "DEBUG: got ERROR: from remote", level: slog.LevelDebug, ok: true},
+		{classify: PrefixClassifier, record: "WARNING: slow", level: slog.LevelWarn, ok: true},
+		{classify: PrefixClassifier, record: "plain message", ok: false},
+		{classify: defaultClassifier, record: "level=DEBUG msg=\"ERROR: inside\"", level: slog.LevelDebug, ok: true},
+		{classify: defaultClassifier, record: "FATAL: stop", level: slog.LevelError + 4, ok: true},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. classify '%s'", index, test.record), func(t *testing.T) {
+			t.Parallel()
+			level, ok := test.classify([]byte(test.record))
+			if ok != test.ok {
+				t.Fatalf("%d. exp ok: %v got: %v", index, test.ok, ok)
+			}
+			if ok && level != test.level {
+				t.Errorf("%d. exp level: %v got: %v", index, test.level, level)
+			}
+		})
+	}
+}
+
+func TestLevelRouter(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	router, err := NewLevelRouter(nil,
+		Route{MinLevel: slog.LevelDebug, Conf: Conf{Dir: "test", Prefix: "debug_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 1024}},
+		Route{MinLevel: slog.LevelError, Conf: Conf{Dir: "test", Prefix: "error_", Middle: testMiddlePartFunc, MaxFiles: 5, MaxBytes: 4096}},
+	)
+	logErr(err, t)
+
+	logger := log.New(router, "", 0)
+	logger.Print("DEBUG: one")
+	logger.Print("ERROR: two")
+	logger.Print("three")
+	slog.New(slog.NewTextHandler(router, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})).Error("four")
+	logErr(router.Close(), t)
+
+	var tests = []struct {
+		file string
+		exp  string
+	}{
+		{file: "test/debug_" + testMiddlePart, exp: "DEBUG: one\nthree\n"},
+		{file: "test/error_" + testMiddlePart, exp: "ERROR: two\nlevel=ERROR msg=four\n"},
+	}
+	for _, test := range tests {
+		got, err := ioutil.ReadFile(filepath.FromSlash(test.file))
+		logErr(err, t)
+		if string(got) != test.exp {
+			t.Errorf("exp '%s' content: '%s' got: '%s'", test.file, test.exp, got)
+		}
+	}
+}
+
+func TestNewLevelRouterErrors(t *testing.T) {
+	conf := Conf{Dir: "test", Prefix: "log_", Middle: testMiddlePartFunc, MaxFiles: 1, MaxBytes: 1}
+	var tests = []struct {
+		routes []Route
+		err    string
+	}{
+		{err: "revolver, router, at least one route is needed"},
+		{
+			routes: []Route{{MinLevel: slog.LevelInfo, Conf: conf}, {MinLevel: slog.LevelInfo, Conf: conf}},
+			err:    "revolver, router, duplicate route for level INFO",
+		},
+		{
+			routes: []Route{{MinLevel: slog.LevelInfo, Conf: Conf{}}},
+			err:    "revolver, router, level INFO, revolver conf can not be empty",
+		},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. new router err: %s", index, test.err), func(t *testing.T) {
+			_, err := NewLevelRouter(nil, test.routes...)
+			if !strings.HasPrefix(errStr(err), test.err) {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+		})
+	}
+}
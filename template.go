@@ -0,0 +1,417 @@
+package revolver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewTemplate is like NewQuick with the difference that the file names are created from the given template.
+// The template is a slash separated path relative to dir e. g. "{host}/{app}-{date}-{seq}.log".
+// Subdirectories are created as needed. Supported placeholders are:
+//
+//	{yyyy} {MM} {dd} {HH} {mm} {ss} {ms}   UTC time components
+//	{date} {time}                          UTC date 2006-01-02 and time 150405
+//	{seq}                                  sequence number, continued from the existing files
+//	{host} {pid}                           hostname and process id
+//	{middle}                               the result of the middle function
+//	{name}                                 the value of name in values
+//
+// CAUTION all files below dir matching the template will eventually be deleted.
+// Files are deleted in the order of their time placeholders, sequence numbers and modification times.
+func NewTemplate(dir, template string, values map[string]string, maxBytes, maxFiles int) (io.WriteCloser, error) {
+	if template == "" {
+		return nil, invalidConf("Template", "can not be empty")
+	}
+	if maxBytes < 1 {
+		return nil, invalidConf("MaxBytes", "must be > 0")
+	}
+	if maxFiles < 1 {
+		return nil, invalidConf("MaxFiles", "must be > 0")
+	}
+	w, err := newWriter(Conf{
+		Dir:      dir,
+		Template: template,
+		Values:   values,
+		MaxBytes: maxBytes,
+		MaxFiles: maxFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+const (
+	partYear = iota
+	partMonth
+	partDay
+	partHour
+	partMinute
+	partSecond
+	partMilli
+	partCount
+)
+
+// stamp holds the time components parsed from a file name.
+type stamp [partCount]int
+
+type timePlaceholder struct {
+	pattern string
+	format  func(t time.Time) string
+	parse   func(s *stamp, val string)
+}
+
+func twoDigits(part int, layout string) timePlaceholder {
+	return timePlaceholder{
+		pattern: `\d{2}`,
+		format:  func(t time.Time) string { return t.Format(layout) },
+		parse:   func(s *stamp, val string) { s[part], _ = strconv.Atoi(val) },
+	}
+}
+
+var timePlaceholders = map[string]timePlaceholder{
+	"yyyy": {
+		pattern: `\d{4}`,
+		format:  func(t time.Time) string { return t.Format("2006") },
+		parse:   func(s *stamp, val string) { s[partYear], _ = strconv.Atoi(val) },
+	},
+	"MM": twoDigits(partMonth, "01"),
+	"dd": twoDigits(partDay, "02"),
+	"HH": twoDigits(partHour, "15"),
+	"mm": twoDigits(partMinute, "04"),
+	"ss": twoDigits(partSecond, "05"),
+	"ms": {
+		pattern: `\d{3}`,
+		format:  func(t time.Time) string { return fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond)) },
+		parse:   func(s *stamp, val string) { s[partMilli], _ = strconv.Atoi(val) },
+	},
+	"date": {
+		pattern: `\d{4}-\d{2}-\d{2}`,
+		format:  func(t time.Time) string { return t.Format("2006-01-02") },
+		parse: func(s *stamp, val string) {
+			s[partYear], _ = strconv.Atoi(val[0:4])
+			s[partMonth], _ = strconv.Atoi(val[5:7])
+			s[partDay], _ = strconv.Atoi(val[8:10])
+		},
+	},
+	"time": {
+		pattern: `\d{6}`,
+		format:  func(t time.Time) string { return t.Format("150405") },
+		parse: func(s *stamp, val string) {
+			s[partHour], _ = strconv.Atoi(val[0:2])
+			s[partMinute], _ = strconv.Atoi(val[2:4])
+			s[partSecond], _ = strconv.Atoi(val[4:6])
+		},
+	},
+}
+
+const (
+	placeholderSeq    = "seq"
+	placeholderHost   = "host"
+	placeholderPID    = "pid"
+	placeholderMiddle = "middle"
+)
+
+// token is either a literal text or a placeholder of a template.
+type token struct {
+	literal     string
+	placeholder string
+}
+
+// fileTemplate is a parsed file name template.
+type fileTemplate struct {
+	tokens []token
+	ext    string            // literal extension, a collision number is placed before it
+	values map[string]string // sanitized custom values
+	groups []string          // placeholder of every regexp group, "" for the collision number
+	match  *regexp.Regexp
+}
+
+func parseTemplate(template string, values map[string]string) (*fileTemplate, error) {
+	slashed := filepath.ToSlash(template)
+	cleaned := path.Clean(slashed)
+	if path.IsAbs(slashed) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return nil, invalidConf("Template", "must be a relative path below Dir")
+	}
+	if strings.HasSuffix(slashed, "/") {
+		return nil, invalidConf("Template", "must end with a file name")
+	}
+	tmpl := &fileTemplate{values: map[string]string{}}
+	for name, val := range values {
+		tmpl.values[name] = sanitize(val)
+	}
+
+	body := slashed
+	if ext := path.Ext(slashed); !strings.ContainsAny(ext, "{}") {
+		tmpl.ext = ext
+		body = slashed[:len(slashed)-len(ext)]
+	}
+	for body != "" {
+		open := strings.IndexByte(body, '{')
+		if open < 0 {
+			tmpl.tokens = append(tmpl.tokens, token{literal: body})
+			break
+		}
+		if open > 0 {
+			tmpl.tokens = append(tmpl.tokens, token{literal: body[:open]})
+		}
+		end := strings.IndexByte(body[open:], '}')
+		if end < 0 {
+			return nil, invalidConf("Template", "has an unclosed placeholder")
+		}
+		name := body[open+1 : open+end]
+		if !tmpl.knows(name) {
+			return nil, invalidConf("Template", "has unknown placeholder {%s}", name)
+		}
+		tmpl.tokens = append(tmpl.tokens, token{placeholder: name})
+		body = body[open+end+1:]
+	}
+
+	expr := &strings.Builder{}
+	expr.WriteString("^")
+	for _, tok := range tmpl.tokens {
+		if tok.placeholder == "" {
+			expr.WriteString(regexp.QuoteMeta(tok.literal))
+			continue
+		}
+		expr.WriteString("(" + tmpl.pattern(tok.placeholder) + ")")
+		tmpl.groups = append(tmpl.groups, tok.placeholder)
+	}
+	expr.WriteString(`(?:_(\d+))?` + regexp.QuoteMeta(tmpl.ext) + "$")
+	tmpl.groups = append(tmpl.groups, "")
+	match, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, invalidConf("Template", "is invalid, %v", err)
+	}
+	tmpl.match = match
+	return tmpl, nil
+}
+
+func (t *fileTemplate) knows(name string) bool {
+	if _, ok := timePlaceholders[name]; ok {
+		return true
+	}
+	if _, ok := t.values[name]; ok {
+		return true
+	}
+	switch name {
+	case placeholderSeq, placeholderHost, placeholderPID, placeholderMiddle:
+		return true
+	}
+	return false
+}
+
+func (t *fileTemplate) pattern(name string) string {
+	if placeholder, ok := timePlaceholders[name]; ok {
+		return placeholder.pattern
+	}
+	if val, ok := t.values[name]; ok {
+		return regexp.QuoteMeta(val)
+	}
+	switch name {
+	case placeholderSeq, placeholderPID:
+		return `\d+`
+	}
+	return `[^/]*?`
+}
+
+// render returns the slash separated file name for the given time, sequence number and middle.
+func (t *fileTemplate) render(now time.Time, seq int64, middle string) string {
+	name := &strings.Builder{}
+	for _, tok := range t.tokens {
+		if tok.placeholder == "" {
+			name.WriteString(tok.literal)
+			continue
+		}
+		if placeholder, ok := timePlaceholders[tok.placeholder]; ok {
+			name.WriteString(placeholder.format(now))
+			continue
+		}
+		if val, ok := t.values[tok.placeholder]; ok {
+			name.WriteString(val)
+			continue
+		}
+		switch tok.placeholder {
+		case placeholderSeq:
+			name.WriteString(fmt.Sprintf("%06d", seq))
+		case placeholderHost:
+			host, err := os.Hostname()
+			if err != nil || host == "" {
+				host = "unknown"
+			}
+			name.WriteString(sanitize(host))
+		case placeholderPID:
+			name.WriteString(strconv.Itoa(os.Getpid()))
+		case placeholderMiddle:
+			name.WriteString(sanitize(middle))
+		}
+	}
+	name.WriteString(t.ext)
+	return name.String()
+}
+
+func (t *fileTemplate) usesSeq() bool {
+	for _, tok := range t.tokens {
+		if tok.placeholder == placeholderSeq {
+			return true
+		}
+	}
+	return false
+}
+
+// templateFile is a file matching a template with the values used to order it.
+type templateFile struct {
+	path    string
+	stamp   stamp
+	seq     int64
+	dup     int64 // collision number, -1 if none
+	modTime time.Time
+}
+
+func (f templateFile) older(o templateFile) bool {
+	for part := range f.stamp {
+		if f.stamp[part] != o.stamp[part] {
+			return f.stamp[part] < o.stamp[part]
+		}
+	}
+	if f.seq != o.seq {
+		return f.seq < o.seq
+	}
+	if f.dup != o.dup {
+		return f.dup < o.dup
+	}
+	if !f.modTime.Equal(o.modTime) {
+		return f.modTime.Before(o.modTime)
+	}
+	return f.path < o.path
+}
+
+// parse returns the ordering values of the given slash separated relative path
+// and whether the path matches the template.
+func (t *fileTemplate) parse(rel string) (templateFile, bool) {
+	groups := t.match.FindStringSubmatch(rel)
+	if groups == nil {
+		return templateFile{}, false
+	}
+	file := templateFile{dup: -1}
+	for index, val := range groups[1:] {
+		name := t.groups[index]
+		switch {
+		case name == "" && val != "":
+			file.dup, _ = strconv.ParseInt(val, 10, 64)
+		case name == placeholderSeq:
+			file.seq, _ = strconv.ParseInt(val, 10, 64)
+		default:
+			if placeholder, ok := timePlaceholders[name]; ok {
+				placeholder.parse(&file.stamp, val)
+			}
+		}
+	}
+	return file, true
+}
+
+// templateNaming names files by a template and owns all files below dir matching it.
+type templateNaming struct {
+	tmpl    *fileTemplate
+	middle  func() string
+	now     func() time.Time
+	seq     int64  // last used sequence number, -1 if not yet read from dir
+	staging string // optional suffix of the file while it is written
+}
+
+func newTemplateNaming(template string, values map[string]string, middle func() string) (*templateNaming, error) {
+	tmpl, err := parseTemplate(template, values)
+	if err != nil {
+		return nil, err
+	}
+	if middle == nil {
+		middle = func() string { return "" }
+	}
+	return &templateNaming{
+		tmpl:   tmpl,
+		middle: middle,
+		now:    func() time.Time { return time.Now().UTC() },
+		seq:    -1,
+	}, nil
+}
+
+func (n *templateNaming) create(dir string) (*os.File, error) {
+	if n.seq < 0 && n.tmpl.usesSeq() {
+		files, err := n.list(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error on create file, %w", err)
+		}
+		n.seq = 0
+		for _, file := range files {
+			if file.seq > n.seq {
+				n.seq = file.seq
+			}
+		}
+	}
+	n.seq++
+
+	rel := n.tmpl.render(n.now(), n.seq, n.middle())
+	name := filepath.Join(filepath.FromSlash(dir), filepath.FromSlash(rel))
+	if err := setupDirs(filepath.Dir(name)); err != nil {
+		return nil, fmt.Errorf("error on create file, %w", err)
+	}
+	return createStagedFile(filepath.Dir(name), "", n.tmpl.ext, n.staging, func() string {
+		return strings.TrimSuffix(filepath.Base(name), n.tmpl.ext)
+	})
+}
+
+func (n *templateNaming) files(dir string) ([]string, error) {
+	list, err := n.list(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(list))
+	for _, file := range list {
+		files = append(files, file.path)
+	}
+	return files, nil
+}
+
+// list walks dir and returns all files matching the template, oldest first.
+func (n *templateNaming) list(dir string) ([]templateFile, error) {
+	dir = filepath.FromSlash(dir)
+	var files []templateFile
+	err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || isSidecar(name) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		if n.staging != "" {
+			rel = strings.TrimSuffix(rel, n.staging)
+		}
+		file, ok := n.tmpl.parse(filepath.ToSlash(rel))
+		if !ok {
+			return nil
+		}
+		file.path = name
+		file.modTime = info.ModTime()
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].older(files[j])
+	})
+	return files, nil
+}
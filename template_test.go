@@ -0,0 +1,200 @@
+package revolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTemplate(t *testing.T) {
+	var tests = []struct {
+		template string
+		values   map[string]string
+		err      string
+	}{
+		{template: "{app}-{date}-{seq}.log", values: map[string]string{"app": "api"}},
+		{template: "{host}/{yyyy}/{MM}/{dd}/{HH}{mm}{ss}{ms}_{pid}.txt"},
+		{template: "log-{middle}"},
+		{template: "/var/log/{seq}.log", err: "revolver conf.Template must be a relative path below Dir"},
+		{template: "../{seq}.log", err: "revolver conf.Template must be a relative path below Dir"},
+		{template: "{date}/", err: "revolver conf.Template must end with a file name"},
+		{template: "{date.log", err: "revolver conf.Template has an unclosed placeholder"},
+		{template: "{app}.log", err: "revolver conf.Template has unknown placeholder {app}"},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. parse '%s' err: %v", index, test.template, test.err), func(t *testing.T) {
+			t.Parallel()
+			_, err := parseTemplate(test.template, test.values)
+			if errStr(err) != test.err {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderAndParse(t *testing.T) {
+	now := time.Date(2026, 10, 17, 13, 45, 1, 123e6, time.UTC)
+	var tests = []struct {
+		template string
+		values   map[string]string
+		exp      string
+		stamp    stamp
+		seq      int64
+	}{
+		{
+			template: "{app}-{date}-{seq}.log",
+			values:   map[string]string{"app": "a/b"},
+			exp:      "a_b-2026-10-17-000007.log",
+			stamp:    stamp{2026, 10, 17},
+			seq:      7,
+		},
+		{
+			template: "{yyyy}/{MM}/{dd}/{time}.{ms}.txt",
+			exp:      "2026/10/17/134501.123.txt",
+			stamp:    stamp{2026, 10, 17, 13, 45, 1, 123},
+		},
+		{
+			template: "{HH}-{mm}-{ss}_{middle}",
+			exp:      "13-45-01_mid_dle",
+			stamp:    stamp{0, 0, 0, 13, 45, 1},
+		},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. render '%s'", index, test.template), func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := parseTemplate(test.template, test.values)
+			logErrAt(err, index, t)
+			got := tmpl.render(now, 7, "mid:dle")
+			if got != test.exp {
+				t.Fatalf("%d. exp name: '%s' got: '%s'", index, test.exp, got)
+			}
+			file, ok := tmpl.parse(got)
+			if !ok {
+				t.Fatalf("%d. exp '%s' to match its template", index, got)
+			}
+			if file.stamp != test.stamp {
+				t.Errorf("%d. exp stamp: %v got: %v", index, test.stamp, file.stamp)
+			}
+			if file.seq != test.seq {
+				t.Errorf("%d. exp seq: %d got: %d", index, test.seq, file.seq)
+			}
+			if _, ok := tmpl.parse("other/" + got); ok {
+				t.Errorf("%d. exp 'other/%s' not to match", index, got)
+			}
+		})
+	}
+}
+
+func TestTemplateOrder(t *testing.T) {
+	tmpl, err := parseTemplate("{date}/log-{seq}.txt", nil)
+	logErr(err, t)
+	names := []string{
+		"2026-10-18/log-000001.txt",
+		"2026-10-17/log-000003_0.txt",
+		"2026-10-17/log-000003.txt",
+		"2026-10-17/log-000002.txt",
+	}
+	var files []templateFile
+	for _, name := range names {
+		file, ok := tmpl.parse(name)
+		if !ok {
+			t.Fatalf("exp '%s' to match", name)
+		}
+		file.path = name
+		files = append(files, file)
+	}
+	exp := []int{3, 2, 1, 0}
+	for position := 0; position < len(exp)-1; position++ {
+		older, newer := files[exp[position]], files[exp[position+1]]
+		if !older.older(newer) || newer.older(older) {
+			t.Errorf("exp '%s' older than '%s'", older.path, newer.path)
+		}
+	}
+}
+
+func TestNewTemplate(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	logErr(os.MkdirAll(filepath.FromSlash("test/api/unrelated"), 0755), t)
+	for seq := 1; seq <= 3; seq++ {
+		name := filepath.FromSlash("test/api/log-00000" + strconv.Itoa(seq) + ".txt")
+		logErr(ioutil.WriteFile(name, []byte{}, 0644), t)
+	}
+	other := filepath.FromSlash("test/api/unrelated/log-000001.txt")
+	logErr(ioutil.WriteFile(other, []byte{}, 0644), t)
+
+	w, err := NewTemplate("test", "{app}/log-{seq}.txt", map[string]string{"app": "api"}, 4, 2)
+	logErr(err, t)
+	defer w.Close()
+	_, err = w.Write([]byte("1234"))
+	logErr(err, t)
+	_, err = w.Write([]byte("5678"))
+	logErr(err, t)
+
+	files, err := ioutil.ReadDir(filepath.FromSlash("test/api"))
+	logErr(err, t)
+	var names []string
+	for _, info := range files {
+		names = append(names, info.Name())
+	}
+	exp := "log-000004.txt log-000005.txt unrelated"
+	if got := strings.Join(names, " "); got != exp {
+		t.Errorf("exp files: '%s' got: '%s'", exp, got)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("exp unrelated file to remain, %v", err)
+	}
+}
+
+func TestNewTemplateErrors(t *testing.T) {
+	var tests = []struct {
+		template string
+		maxBytes int
+		maxFiles int
+		err      string
+	}{
+		{template: "", err: "revolver conf.Template can not be empty"},
+		{template: "{seq}", maxBytes: 0, err: "revolver conf.MaxBytes must be > 0"},
+		{template: "{seq}", maxBytes: 1, maxFiles: 0, err: "revolver conf.MaxFiles must be > 0"},
+		{template: "{nope}", maxBytes: 1, maxFiles: 1, err: "revolver conf.Template has unknown placeholder {nope}"},
+	}
+	for index, test := range tests {
+		index, test := index, test
+		t.Run(fmt.Sprintf("%d. new template err: %s", index, test.err), func(t *testing.T) {
+			t.Parallel()
+			_, err := NewTemplate("test", test.template, nil, test.maxBytes, test.maxFiles)
+			if errStr(err) != test.err {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+		})
+	}
+}
+
+func TestTemplateCreateCollision(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	naming, err := newTemplateNaming("{app}/static.log", map[string]string{"app": "api"}, nil)
+	logErr(err, t)
+	for _, exp := range []string{"static.log", "static_0.log", "static_1.log"} {
+		file, err := naming.create("test")
+		logErr(err, t)
+		logErr(file.Close(), t)
+		if got := filepath.Base(file.Name()); got != exp {
+			t.Errorf("exp name: '%s' got: '%s'", exp, got)
+		}
+	}
+	files, err := naming.files("test")
+	logErr(err, t)
+	if len(files) != 3 || !strings.HasSuffix(files[0], "static.log") {
+		t.Errorf("exp 3 files with static.log first got: %v", files)
+	}
+}
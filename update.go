@@ -0,0 +1,157 @@
+package revolver
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+)
+
+// updatable are the conf fields UpdateConfig can change.
+var updatable = map[string]bool{
+	"MaxBytes":  true,
+	"MaxFiles":  true,
+	"Suffix":    true,
+	"Middle":    true,
+	"Pin":       true,
+	"MaxPinned": true,
+}
+
+// UpdateConfig applies the options for MaxBytes, MaxFiles, Suffix, Middle, Pin and MaxPinned to the
+// running writer, e. g. WithRetention and WithMaxBytes. The updated conf is validated like the conf
+// of NewWriter and swapped at once. Changes of other fields return a ConfError, as do a Suffix
+// with a Template and a Middle with a Template without the {middle} placeholder.
+// Retention is applied immediately and the current file is kept. A lower MaxBytes applies to the
+// current file, the naming changes from the next rotation on. With Preallocate the current file
+// can't grow, so it is closed if MaxBytes is raised.
+func (l *Writer) UpdateConfig(options ...Option) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.stopped == ErrClosed {
+		return ErrClosed
+	}
+	conf := l.conf
+	for _, option := range options {
+		option(&conf)
+	}
+	if err := validUpdate(l.conf, conf); err != nil {
+		return err
+	}
+	if err := ValidConf(conf); err != nil {
+		return err
+	}
+	grown := conf.MaxBytes > l.maxBytes
+	l.conf = conf
+	l.naming = renamed(l.naming, conf)
+	l.maxBytes, l.maxFiles = conf.MaxBytes, conf.MaxFiles
+	l.pin = newPinning(conf)
+	if l.pin != nil {
+		l.pin.first = false // the first file is already created
+	}
+	if grown && l.mapping != nil {
+		if err := l.close(); err != nil {
+			return err
+		}
+	}
+	return l.retain()
+}
+
+// validUpdate returns a ConfError if the update changes a field which can't be updated.
+func validUpdate(before, after Conf) error {
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	for index := 0; index < b.NumField(); index++ {
+		name := b.Type().Field(index).Name
+		if !updatable[name] && !sameField(b.Field(index), a.Field(index)) {
+			return invalidConf(name, "can not be updated")
+		}
+	}
+	if before.Template == "" {
+		return nil
+	}
+	if before.Suffix != after.Suffix {
+		return invalidConf("Suffix", "can not be updated with a Template")
+	}
+	if !sameField(b.FieldByName("Middle"), a.FieldByName("Middle")) && !usesMiddle(after.Template, after.Values) {
+		return invalidConf("Middle", "is not used by the Template")
+	}
+	return nil
+}
+
+// sameField reports whether the field is unchanged, funcs are compared by their code pointer
+// since they are never deeply equal.
+func sameField(before, after reflect.Value) bool {
+	if before.Kind() == reflect.Func {
+		return before.Pointer() == after.Pointer()
+	}
+	return reflect.DeepEqual(before.Interface(), after.Interface())
+}
+
+// usesMiddle reports whether the template has the {middle} placeholder.
+func usesMiddle(template string, values map[string]string) bool {
+	tmpl, err := parseTemplate(template, values)
+	if err != nil {
+		return false
+	}
+	for _, tok := range tmpl.tokens {
+		if tok.placeholder == placeholderMiddle {
+			return true
+		}
+	}
+	return false
+}
+
+// renamed returns the naming with the suffix and middle of the conf.
+func renamed(n naming, conf Conf) naming {
+	switch n := n.(type) {
+	case *indexNaming:
+		n.naming = renamed(n.naming, conf)
+		return n
+	case prefixNaming:
+		n.suffix, n.middle = conf.Suffix, conf.Middle
+		return n
+	case *templateNaming:
+		n.middle = conf.Middle
+		if n.middle == nil {
+			n.middle = func() string { return "" }
+		}
+		return n
+	}
+	return n
+}
+
+// retain removes the oldest files over the limits and keeps the current file.
+// Without a current file the limits are applied when the next file is created.
+func (l *Writer) retain() error {
+	if l.file == nil {
+		return nil
+	}
+	var removed int
+	var err error
+	if l.pin != nil {
+		removed, err = l.pin.retain(l.dir, l.naming, l.file.Name(), l.maxFiles)
+	} else {
+		removed, err = l.removeOthers()
+	}
+	l.metrics.Removed(removed)
+	if err != nil {
+		return &RotateError{Op: "remove", Path: l.dir, Err: err}
+	}
+	return nil
+}
+
+// removeOthers removes the oldest files except the current file until maxFiles are left.
+// The current file is excluded by name, since equal mod times don't order it last.
+func (l *Writer) removeOthers() (int, error) {
+	files, err := l.naming.files(l.dir)
+	if err != nil {
+		return 0, fmt.Errorf("error while counting files, %w", err)
+	}
+	var others []string
+	for _, name := range files {
+		if filepath.Clean(name) != filepath.Clean(l.file.Name()) {
+			others = append(others, name)
+		}
+	}
+	removed, err := removeOldest(l.dir, others, l.maxFiles-1)
+	forgetRemoved(l.naming, others[:removed], err)
+	return removed, err
+}
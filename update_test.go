@@ -0,0 +1,183 @@
+package revolver
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	var tests = []struct {
+		options []Option
+		err     string
+		records []string
+		files   string
+	}{
+		{
+			options: []Option{WithRetention(2)},
+			files:   "[log_2 log_3]",
+		},
+		{
+			options: []Option{WithRetention(2)},
+			records: []string{"44444"},
+			files:   "[log_3 log_4]",
+		},
+		{
+			options: []Option{WithRetention(5), WithSuffix(".txt"), WithMiddle(func() string { return "new" })},
+			records: []string{"44444", "55555"},
+			files:   "[log_1 log_2 log_3 log_new.txt log_new_0.txt]",
+		},
+		{
+			options: []Option{WithMaxBytes(4)},
+			records: []string{"4444", "5555"},
+			files:   "[log_3 log_4 log_5]",
+		},
+		{
+			options: []Option{WithMaxBytes(16)},
+			records: []string{"4444", "5555"},
+			files:   "[log_1 log_2 log_3]",
+		},
+		{
+			options: []Option{WithMaxPinned(1), WithPin(func(name string) bool { return name == "log_1" })},
+			records: []string{"44444", "55555"},
+			files:   "[log_1 log_3 log_4 log_5]",
+		},
+		{
+			options: []Option{WithRetention(0)},
+			err:     "revolver conf.MaxFiles must be > 0",
+			files:   "[log_1 log_2 log_3]",
+		},
+		{
+			options: []Option{WithRetention(1), WithDir("other")},
+			err:     "revolver conf.Dir can not be updated",
+			files:   "[log_1 log_2 log_3]",
+		},
+		{
+			options: []Option{WithDir("")},
+			err:     "revolver conf.Dir can not be updated",
+			files:   "[log_1 log_2 log_3]",
+		},
+		{
+			options: []Option{WithHeader(func() []byte { return nil })},
+			err:     "revolver conf.Header can not be updated",
+			files:   "[log_1 log_2 log_3]",
+		},
+		{
+			options: []Option{WithStaging(".inprogress")},
+			err:     "revolver conf.Staging can not be updated",
+			files:   "[log_1 log_2 log_3]",
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. update config err: %s", index, test.err), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			w, err := NewWriter(
+				WithDir("test"),
+				WithPrefix("log_"),
+				WithSuffix(""),
+				WithMiddle(testCounterMiddle()),
+				WithMaxBytes(8),
+				WithRetention(3),
+			)
+			logErrAt(err, index, t)
+			for _, record := range []string{"11111111", "22222222", "3333"} {
+				_, err := w.Write([]byte(record))
+				logErrAt(err, index, t)
+				time.Sleep(10 * time.Millisecond) // distinct mod times
+			}
+			if err := w.UpdateConfig(test.options...); errStr(err) != test.err {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			for _, record := range test.records {
+				_, err := w.Write([]byte(record))
+				logErrAt(err, index, t)
+				time.Sleep(10 * time.Millisecond)
+			}
+			logErrAt(w.Close(), index, t)
+			if got := fmt.Sprint(dirNames(t)); got != test.files {
+				t.Errorf("%d. exp files: %s got: %s", index, test.files, got)
+			}
+			if err := w.UpdateConfig(WithRetention(1)); err != ErrClosed {
+				t.Errorf("%d. exp closed err got: %v", index, err)
+			}
+		})
+	}
+}
+
+func TestUpdateConfigEqualModTimes(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := NewWriter(WithDir("test"), WithPrefix("log_"), WithSuffix(""), WithMiddle(testCounterMiddle()),
+		WithMaxBytes(2), WithRetention(10))
+	logErr(err, t)
+	for write := 1; write <= 10; write++ {
+		_, err := w.Write([]byte(fmt.Sprintf("%02d", write)))
+		logErr(err, t)
+	}
+	names, err := prefixNaming{prefix: "log_"}.files("test")
+	logErr(err, t)
+	same := time.Now().Add(-time.Hour)
+	for _, name := range names {
+		logErr(os.Chtimes(name, same, same), t)
+	}
+	logErr(w.UpdateConfig(WithRetention(2)), t)
+	if _, err := os.Stat(w.file.Name()); err != nil {
+		t.Errorf("exp current file to be kept got: %v", err)
+	}
+	logErr(w.Close(), t)
+	if got := len(dirNames(t)); got != 2 {
+		t.Errorf("exp 2 files got: %d", got)
+	}
+}
+
+func TestUpdateTemplateConfig(t *testing.T) {
+	var tests = []struct {
+		template string
+		options  []Option
+		err      string
+		files    string
+	}{
+		{
+			template: "{middle}-{seq}.log",
+			options:  []Option{WithMiddle(func() string { return "new" })},
+			files:    "[a-000001.log new-000002.log]",
+		},
+		{
+			template: "{middle}-{seq}.log",
+			options:  []Option{WithSuffix(".json")},
+			err:      "revolver conf.Suffix can not be updated with a Template",
+			files:    "[a-000001.log a-000002.log]",
+		},
+		{
+			template: "{seq}.log",
+			options:  []Option{WithMiddle(func() string { return "new" })},
+			err:      "revolver conf.Middle is not used by the Template",
+			files:    "[000001.log 000002.log]",
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. update template config err: %s", index, test.err), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			w, err := NewWriter(WithDir("test"), WithTemplate(test.template, nil),
+				WithMiddle(func() string { return "a" }), WithMaxBytes(4))
+			logErrAt(err, index, t)
+			if err := w.UpdateConfig(test.options...); errStr(err) != test.err {
+				t.Errorf("%d. exp err: '%s' got: '%v'", index, test.err, err)
+			}
+			for _, record := range []string{"1111", "2222"} {
+				_, err := w.Write([]byte(record))
+				logErrAt(err, index, t)
+			}
+			logErrAt(w.Close(), index, t)
+			if got := fmt.Sprint(dirNames(t)); got != test.files {
+				t.Errorf("%d. exp files: %s got: %s", index, test.files, got)
+			}
+		})
+	}
+}
@@ -2,29 +2,55 @@
 package revolver
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
-type revWriter struct {
+// Writer writes revolving files, it is returned by NewWriter and implements
+// ContextWriter and Releaser. The writers returned by New and NewQuick are of this type.
+type Writer struct {
+	conf     Conf // validated conf, updated by UpdateConfig
 	dir      string
-	prefix   string
-	suffix   string
-	middle   func() string
-	maxBytes int
-	maxFiles int
-	size     int
-	file     *os.File
-	lock     *sync.Mutex // synchronizes file operations
+	naming   naming
+	header   func() []byte
+	metrics  Metrics
+	checksum ChecksumMode
+	prev     sidecar // sidecar of the last closed file, the previous link of the checksum chain
+	limit    *limiter
+	pin      *pinning
+	dedup    *deduper
+	staging  string // suffix of the current file until it is published
+	redact   *redactor
+
+	encryption  *Encryption
+	sealer      *sealer // encrypts into the current file, kept after close for sizeOf
+	preallocate bool
+	mapping     *mapping // maps the current preallocated file
+	base        int      // size of a new file after the headers
+	maxBytes    int
+	maxFiles    int
+	size        int
+	file        *os.File
+	stopped     error       // ErrReleased or ErrClosed, returned by writes
+	lock        *sync.Mutex // synchronizes file operations
+}
+
+// Releaser is implemented by the writers returned by New, see Release and Reopen.
+type Releaser interface {
+	Release() error
+	Reopen() error
 }
 
 // Must wraps the call to NewWriter and returns a io.WriteCloser or panics
 func Must(w io.WriteCloser, err error) io.WriteCloser {
 	if err != nil {
-		panic(fmt.Errorf("could not create revolving log writer, %v", err))
+		panic(fmt.Errorf("could not create revolving log writer, %w", err))
 	}
 	return w
 }
@@ -36,99 +62,418 @@ func New(conf Conf) (io.WriteCloser, error) {
 	if err := ValidConf(conf); err != nil {
 		return nil, err
 	}
-	conf = clean(conf)
-	return NewQuick(conf.Dir, conf.Prefix, conf.Suffix, conf.Middle, conf.MaxBytes, conf.MaxFiles)
+	w, err := newWriter(clean(conf))
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
+// NewQuick is deprecated. Use NewWriter instead.
 // NewQuick is like New with the difference that no Conf struct is needed.
 // Calling New will always create a new file even if there is space left in other files.
 // If the configured directory doesn't exist it will be created.
 func NewQuick(dir, prefix, suffix string, middle func() string, maxBytes, maxFiles int) (io.WriteCloser, error) {
 	if prefix == "" {
-		return nil, fmt.Errorf("revolver, prefix can not be empty")
+		return nil, invalidConf("Prefix", "can not be empty")
 	}
 	if middle == nil {
 		middle = func() string { return "" }
 	}
 	if maxBytes < 1 {
-		return nil, fmt.Errorf("revolver, maxBytes must be > 0")
+		return nil, invalidConf("MaxBytes", "must be > 0")
 	}
 	if maxFiles < 1 {
-		return nil, fmt.Errorf("revolver, maxFiles must be > 0")
+		return nil, invalidConf("MaxFiles", "must be > 0")
 	}
+	w, err := newWriter(Conf{
+		Dir:      dir,
+		Prefix:   prefix,
+		Suffix:   suffix,
+		Middle:   middle,
+		MaxBytes: maxBytes,
+		MaxFiles: maxFiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
 
-	if err := setupDirs(dir); err != nil {
-		return nil, fmt.Errorf("revolver setup, %v", err)
+// newWriter sets up the dir, removes surplus files and creates the first file for the validated conf.
+func newWriter(conf Conf) (*Writer, error) {
+	naming, err := newNaming(conf)
+	if err != nil {
+		return nil, err
 	}
-	if err := countAndRemoveFiles(dir, prefix, maxFiles); err != nil {
-		return nil, fmt.Errorf("revolver, remove, %v", err)
+
+	if err := setupDirs(conf.Dir); err != nil {
+		return nil, fmt.Errorf("revolver setup, %w", err)
 	}
 
-	file, err := createFile(dir, prefix, suffix, middle)
-	if err != nil {
-		return nil, fmt.Errorf("revolver, create, %v", err)
+	if conf.Metrics == nil {
+		conf.Metrics = noMetrics{}
 	}
+	l := &Writer{
+		conf:     conf,
+		dir:      filepath.Clean(conf.Dir),
+		naming:   naming,
+		header:   conf.Header,
+		metrics:  conf.Metrics,
+		checksum: conf.Checksum,
+		limit:    newLimiter(conf.Limit),
+		pin:      newPinning(conf),
+		staging:  conf.Staging,
+		redact:   newRedactor(conf.Redact),
 
-	return &revWriter{
-		dir:      filepath.Clean(dir),
-		prefix:   filepath.Clean(prefix),
-		suffix:   suffix,
-		middle:   middle,
-		maxBytes: maxBytes,
-		maxFiles: maxFiles,
-		file:     file,
-		lock:     &sync.Mutex{},
-	}, nil
+		encryption:  conf.Encryption,
+		preallocate: conf.Preallocate,
+		maxBytes:    conf.MaxBytes,
+		maxFiles:    conf.MaxFiles,
+		lock:        &sync.Mutex{},
+	}
+	l.dedup = newDeduper(conf.Dedup, l.expiredSummary)
+	if l.checksum == ChecksumChain {
+		files, err := naming.files(conf.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("revolver, checksum, %w", err)
+		}
+		l.prev = newestSidecar(files)
+	}
+	if err := l.recover(conf.Recover); err != nil {
+		return nil, err
+	}
+	if err := l.open(); err != nil {
+		l.close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func newNaming(conf Conf) (naming, error) {
+	var n naming = prefixNaming{
+		prefix:    filepath.Clean(conf.Prefix),
+		suffix:    conf.Suffix,
+		middle:    conf.Middle,
+		partition: conf.Partition,
+		staging:   conf.Staging,
+	}
+	if conf.Template != "" {
+		tmpl, err := newTemplateNaming(conf.Template, conf.Values, conf.Middle)
+		if err != nil {
+			return nil, err
+		}
+		tmpl.staging = conf.Staging
+		n = tmpl
+	}
+	if conf.Index {
+		n = newIndexNaming(n, conf.IndexResync)
+	}
+	return n, nil
 }
 
 // Write writes the given bytes into the current file. The specifics of the file are specified on writer creation.
 // If there is not enough file space left,surplus files will be deleted and a new file will be created.
-func (l *revWriter) Write(p []byte) (n int, err error) {
+// After Close it returns ErrClosed and after Release ErrReleased.
+func (l *Writer) Write(p []byte) (n int, err error) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
+	return l.record(p, time.Now())
+}
+
+// record redacts, deduplicates, limits and writes the record.
+func (l *Writer) record(p []byte, start time.Time) (n int, err error) {
+	if l.stopped != nil {
+		return 0, l.stopped
+	}
+	if err := l.waitLimit(len(p)); err != nil {
+		return 0, err
+	}
+	if l.redact != nil {
+		record, count := l.redact.apply(p)
+		l.metrics.Redacted(count)
+		n, err = l.writeRecord(record, start)
+		if err == nil {
+			n = len(p)
+		}
+		return n, err
+	}
+	return l.writeRecord(p, start)
+}
+
+// writeRecord deduplicates, limits and writes the redacted record.
+func (l *Writer) writeRecord(p []byte, start time.Time) (n int, err error) {
+	if l.dedup != nil {
+		if l.dedup.repeat(p) {
+			return len(p), nil
+		}
+		if err := l.writeSummary(); err != nil {
+			l.report(0, err, start)
+			return 0, err
+		}
+	}
+	if l.limit != nil {
+		return l.limited(p, start)
+	}
+	n, err = l.write(p)
+	l.report(n, err, start)
+	return n, err
+}
+
+// limited writes p if the limit admits it and the marker of earlier suppressed writes before.
+// Suppressed writes return len(p) and no error.
+func (l *Writer) limited(p []byte, start time.Time) (n int, err error) {
+	if !l.limit.admit(len(p)) {
+		l.limit.suppress(len(p))
+		return len(p), nil
+	}
+	if marker := l.limit.marker(); marker != nil {
+		_, err := l.write(marker)
+		if err == errSuppressed {
+			l.limit.suppress(len(p))
+			return len(p), nil
+		}
+		if err != nil {
+			l.report(0, err, start)
+			return 0, err
+		}
+		l.limit.suppressed = 0
+	}
+	n, err = l.write(p)
+	if err == errSuppressed {
+		l.limit.suppress(len(p))
+		return len(p), nil
+	}
+	l.report(n, err, start)
+	return n, err
+}
 
-	size := len(p)
-	if size > l.maxBytes {
-		return 0, fmt.Errorf("revolver, bytes to write %d over max file size %d", size, l.maxBytes)
+func (l *Writer) report(n int, err error, start time.Time) {
+	if err != nil {
+		l.metrics.WriteFailed()
+	} else {
+		l.metrics.Written(n, time.Since(start))
+	}
+	l.metrics.FileSize(l.size)
+}
+
+func (l *Writer) write(p []byte) (n int, err error) {
+	size := l.sizeOf(len(p))
+	if size > l.maxBytes-l.base {
+		return 0, fmt.Errorf("%w, %d bytes over max file size %d", ErrWriteTooLarge, size, l.maxBytes)
 	}
 	if l.file == nil || l.size+size > l.maxBytes {
+		if l.limit != nil && !l.limit.rotate() {
+			return 0, errSuppressed
+		}
 		if err := l.close(); err != nil {
-			return 0, fmt.Errorf("revolver, close, %v", err)
+			return 0, err
+		}
+		if err := l.open(); err != nil {
+			return 0, err
+		}
+		l.metrics.Rotated()
+		if l.size+size > l.maxBytes {
+			return 0, fmt.Errorf("%w, %d bytes over max file size %d after header", ErrWriteTooLarge, size, l.maxBytes)
 		}
+	}
+
+	l.size += size
+	return l.out().Write(p)
+
+}
+
+// publish renames the closed staged file to its final name and returns the final name.
+func (l *Writer) publish(staged string) (string, error) {
+	final := strings.TrimSuffix(staged, l.staging)
+	if err := os.Rename(staged, final); err != nil {
+		return staged, fmt.Errorf("error on publish, %w", err)
+	}
+	renameIndexed(l.naming, staged, final)
+	return final, nil
+}
+
+// out returns the writer for the current file, which encrypts if configured.
+func (l *Writer) out() io.Writer {
+	if l.sealer != nil {
+		return l.sealer
+	}
+	if l.mapping != nil {
+		return l.mapping
+	}
+	return l.file
+}
+
+// sizeOf returns the bytes added to the current file when n bytes are written.
+func (l *Writer) sizeOf(n int) int {
+	if l.sealer != nil {
+		return l.sealer.size(n)
+	}
+	return n
+}
 
-		if err := countAndRemoveFiles(l.dir, l.prefix, l.maxFiles); err != nil {
-			return 0, fmt.Errorf("revolver, remove, %v", err)
+// open removes surplus files, creates the next file and writes the header into it.
+func (l *Writer) open() error {
+	if l.pin == nil {
+		removed, err := removeSurplus(l.dir, l.naming, l.maxFiles)
+		l.metrics.Removed(removed)
+		if err != nil {
+			return &RotateError{Op: "remove", Path: l.dir, Err: err}
 		}
+	}
 
-		file, err := createFile(l.dir, l.prefix, l.suffix, l.middle)
+	file, err := l.naming.create(l.dir)
+	if err != nil {
+		return &RotateError{Op: "create", Path: l.dir, Err: err}
+	}
+	if l.pin != nil {
+		// the created file is needed to tell whether it is pinned
+		removed, err := l.pin.retain(l.dir, l.naming, file.Name(), l.maxFiles)
+		l.metrics.Removed(removed)
 		if err != nil {
-			return 0, fmt.Errorf("revolver, create, %v", err)
+			file.Close()
+			os.Remove(file.Name())
+			forgetRemoved(l.naming, []string{file.Name()}, nil)
+			return &RotateError{Op: "remove", Path: l.dir, Err: err}
+		}
+	}
+	l.file = file
+	if err := l.prepare(); err != nil {
+		l.discard()
+		return err
+	}
+	if err := l.writeSummary(); err != nil {
+		return err
+	}
+	return nil
+}
 
+// prepare maps, encrypts and writes the header of the created current file.
+func (l *Writer) prepare() error {
+	file := l.file
+	l.size = 0
+	var dst io.Writer = file
+	if l.preallocate {
+		mapping, err := newMapping(file, l.maxBytes)
+		switch {
+		case errors.Is(err, errNoFallocate):
+			// written without preallocation
+		case err != nil:
+			return &RotateError{Op: "preallocate", Path: file.Name(), Err: err}
+		default:
+			l.mapping = mapping
+			dst = mapping
+		}
+	}
+	if l.encryption != nil {
+		sealer, n, err := newSealer(dst, l.encryption)
+		l.size = n
+		if err != nil {
+			return &RotateError{Op: "encryption", Path: file.Name(), Err: err}
+		}
+		l.sealer = sealer
+		l.size += sealer.finalSize() // reserved for the final chunk written on close
+	}
+	if l.header != nil {
+		header := l.header()
+		l.size += l.sizeOf(len(header))
+		if _, err := l.out().Write(header); err != nil {
+			return &RotateError{Op: "header", Path: file.Name(), Err: err}
 		}
-		l.file = file
-		l.size = 0
 	}
+	l.base = l.size
+	return nil
+}
 
-	l.size += size
-	return l.file.Write(p)
+// discard closes and removes the current file if it could not be prepared, so the next write
+// creates a new file.
+func (l *Writer) discard() {
+	if l.mapping != nil {
+		l.mapping.close()
+		l.mapping = nil
+	}
+	l.sealer = nil
+	l.file.Close()
+	os.Remove(l.file.Name())
+	forgetRemoved(l.naming, []string{l.file.Name()}, nil)
+	l.file = nil
+}
+
+// Close closes the current log file, later writes return ErrClosed.
+// Closing a closed writer returns nil.
+func (l *Writer) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.stopped = ErrClosed
+	err := l.flushSummary()
+	if closeErr := l.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
 
+// Release closes the current log file like Close, e. g. to hand it over to a log shipper.
+// Until Reopen writes return ErrReleased.
+func (l *Writer) Release() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.stopped == ErrClosed {
+		return ErrClosed
+	}
+	l.stopped = ErrReleased
+	err := l.flushSummary()
+	if closeErr := l.close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-// Close closes the current log file and sets the writer reference to nil.
-// If the file reference is nil, the returned err is always be nil.
-// Writing to a nil referencing writer cleans up surplus files and creates a new file.
-func (l *revWriter) Close() error {
+// Reopen removes surplus files and creates a new file after Release.
+// Reopening a writer which is not released does nothing.
+func (l *Writer) Reopen() error {
 	l.lock.Lock()
 	defer l.lock.Unlock()
-	return l.close()
+	if l.stopped != ErrReleased {
+		return l.stopped
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+	l.stopped = nil
+	return nil
 }
 
-func (l *revWriter) close() error {
+func (l *Writer) close() error {
 	if l.file == nil {
 		return nil
 	}
-	err := l.file.Close()
+	err := l.writeSummary()
+	if l.sealer != nil {
+		if finishErr := l.sealer.finish(); err == nil {
+			err = finishErr
+		}
+		l.sealer = nil
+	}
+	if l.mapping != nil {
+		if unmapErr := l.mapping.close(); err == nil {
+			err = unmapErr
+		}
+		l.mapping = nil
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	name := l.file.Name()
 	l.file = nil
-	return err
+	if err == nil && l.staging != "" {
+		name, err = l.publish(name)
+	}
+	if err == nil && l.checksum != ChecksumNone {
+		err = l.writeChecksum(name)
+	}
+	if err != nil {
+		return &RotateError{Op: "close", Path: name, Err: err}
+	}
+	return nil
 
 }
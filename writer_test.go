@@ -2,6 +2,7 @@ package revolver
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -22,6 +23,15 @@ var (
 	}
 )
 
+// testCounterMiddle returns a middle which counts the files from 1.
+func testCounterMiddle() func() string {
+	seq := 0
+	return func() string {
+		seq++
+		return strconv.Itoa(seq)
+	}
+}
+
 func TestNew(t *testing.T) {
 	var tests = []struct {
 		before func(t *testing.T)
@@ -154,14 +164,14 @@ func TestNew(t *testing.T) {
 
 func TestWrite(t *testing.T) {
 	var tests = []struct {
-		before func(w *revWriter, t *testing.T)
+		before func(w *Writer, t *testing.T)
 		after  func(t *testing.T)
 		conf   Conf
 		bytes  []byte
 		err    string
 	}{
 		{
-			before: func(w *revWriter, t *testing.T) {},
+			before: func(w *Writer, t *testing.T) {},
 			after: func(t *testing.T) {
 				logErr(os.RemoveAll("test"), t)
 			},
@@ -173,10 +183,10 @@ func TestWrite(t *testing.T) {
 				MaxBytes: 5,
 			},
 			bytes: []byte{1, 2, 3, 4, 5, 6},
-			err:   "revolver, bytes to write 6 over max file size 5",
+			err:   "revolver, write too large, 6 bytes over max file size 5",
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 				logErr(os.Chmod("test", 0000), t)
 				w.size = 5
 			},
@@ -195,7 +205,7 @@ func TestWrite(t *testing.T) {
 			err:   "revolver, remove, error while counting files,",
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 				file, err := os.Create(filepath.FromSlash("test/log_test"))
 				logErr(err, t)
 				logErr(file.Close(), t)
@@ -217,7 +227,7 @@ func TestWrite(t *testing.T) {
 			err:   "revolver, remove, ",
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 				file, err := os.Create(filepath.FromSlash("test/log_test"))
 				logErr(err, t)
 				logErr(file.Close(), t)
@@ -238,7 +248,7 @@ func TestWrite(t *testing.T) {
 			err:   "revolver, close,",
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 				w.size = 5
 				logErr(os.Chmod("test", 0555), t)
 			},
@@ -257,7 +267,7 @@ func TestWrite(t *testing.T) {
 			err:   "revolver, create, ",
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 				w.size = 5
 			},
 			after: func(t *testing.T) {
@@ -273,7 +283,7 @@ func TestWrite(t *testing.T) {
 			bytes: []byte("This..."),
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 			},
 			after: func(t *testing.T) {
 				logErr(os.RemoveAll("test"), t)
@@ -288,7 +298,7 @@ func TestWrite(t *testing.T) {
 			bytes: []byte("This..."),
 		},
 		{
-			before: func(w *revWriter, t *testing.T) {
+			before: func(w *Writer, t *testing.T) {
 				for file := 0; file < 3; file++ {
 					file, err := os.Create("test/log_" + testMiddlePart + "_" + strconv.Itoa(file) + ".txt")
 					logErr(err, t)
@@ -317,7 +327,7 @@ func TestWrite(t *testing.T) {
 			logErrAt(err, index, t)
 			defer w.Close()
 
-			log := w.(*revWriter)
+			log := w.(*Writer)
 			test.before(log, t)
 			defer test.after(t)
 
@@ -367,6 +377,50 @@ func TestCloseTwice(t *testing.T) {
 	}
 }
 
+func TestLifecycle(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	w, err := New(Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   testCounterMiddle(),
+		MaxFiles: 10,
+		MaxBytes: 1024,
+	})
+	logErr(err, t)
+	l := w.(*Writer)
+	var tests = []struct {
+		op  string
+		do  func() error
+		exp error
+	}{
+		{op: "write", do: func() error { _, err := w.Write([]byte("1")); return err }},
+		{op: "reopen", do: l.Reopen},
+		{op: "release", do: l.Release},
+		{op: "write", do: func() error { _, err := w.Write([]byte("released")); return err }, exp: ErrReleased},
+		{op: "release", do: l.Release},
+		{op: "reopen", do: l.Reopen},
+		{op: "write", do: func() error { _, err := w.Write([]byte("2")); return err }},
+		{op: "close", do: w.Close},
+		{op: "write", do: func() error { _, err := w.Write([]byte("closed")); return err }, exp: ErrClosed},
+		{op: "release", do: l.Release, exp: ErrClosed},
+		{op: "reopen", do: l.Reopen, exp: ErrClosed},
+		{op: "close", do: w.Close},
+	}
+	for index, test := range tests {
+		if err := test.do(); err != test.exp {
+			t.Errorf("%d. exp %s err: %v got: %v", index, test.op, test.exp, err)
+		}
+	}
+	if got := dirNames(t); fmt.Sprint(got) != "[log_1 log_2]" {
+		t.Errorf("exp files: [log_1 log_2] got: %v", got)
+	}
+	if got := readAll(t); got != "12" {
+		t.Errorf("exp content: %q got: %q", "12", got)
+	}
+}
+
 func TestMust(t *testing.T) {
 	var tests = []struct {
 		conf  Conf
@@ -452,18 +506,18 @@ func TestNewQuick(t *testing.T) {
 	}{
 		{
 			prefix: "",
-			err:    "revolver, prefix can not be empty",
+			err:    "revolver conf.Prefix can not be empty",
 		},
 		{
 			prefix:   "test_",
 			maxBytes: 0,
-			err:      "revolver, maxBytes must be > 0",
+			err:      "revolver conf.MaxBytes must be > 0",
 		},
 		{
 			prefix:   "test_",
 			maxBytes: 1,
 			maxFiles: 0,
-			err:      "revolver, maxFiles must be > 0",
+			err:      "revolver conf.MaxFiles must be > 0",
 		},
 	}
 	for index, test := range tests {
@@ -490,14 +544,112 @@ func TestDefaultMiddle(t *testing.T) {
 	defer func() {
 		logErr(w.Close(), t)
 	}()
-	rev := w.(*revWriter)
+	rev := w.(*Writer)
 
-	got := rev.middle()
+	got := rev.naming.(prefixNaming).middle()
 	if got != "" {
 		t.Errorf("exp middle to be empty got: %s", got)
 	}
 }
 
+func TestHeader(t *testing.T) {
+	defer func() {
+		logErr(os.RemoveAll("test"), t)
+	}()
+	count := 0
+	conf := Conf{
+		Dir:      "test",
+		Prefix:   "log_",
+		Middle:   func() string { count++; return strconv.Itoa(count) },
+		MaxFiles: 3,
+		MaxBytes: 8,
+		Header:   func() []byte { return []byte("head\n") },
+	}
+	w, err := New(conf)
+	logErr(err, t)
+	_, err = w.Write([]byte("123"))
+	logErr(err, t)
+	_, err = w.Write([]byte("456"))
+	logErr(err, t)
+	if _, err := w.Write([]byte("789ab")); !errors.Is(err, ErrWriteTooLarge) || !strings.HasPrefix(errStr(err), "revolver, write too large, 5 bytes over max file size 8") {
+		t.Errorf("exp header size err got: '%v'", err)
+	}
+	logErr(w.Close(), t)
+
+	for name, exp := range map[string]string{"log_1": "head\n123", "log_2": "head\n456"} {
+		got, err := ioutil.ReadFile(filepath.Join("test", name))
+		logErr(err, t)
+		if string(got) != exp {
+			t.Errorf("exp '%s' content: '%s' got: '%s'", name, exp, got)
+		}
+	}
+}
+
+func TestStaging(t *testing.T) {
+	var tests = []struct {
+		conf  Conf
+		final []string
+	}{
+		{
+			conf:  Conf{Prefix: "log_", Suffix: ".txt"},
+			final: []string{"log_1.txt", "log_1.txt.sha256", "log_2.txt", "log_2.txt.sha256", "log_3.txt", "log_3.txt.sha256"},
+		},
+		{
+			conf:  Conf{Template: "log-{seq}.log", Index: true},
+			final: []string{"log-000001.log", "log-000001.log.sha256", "log-000002.log", "log-000002.log.sha256", "log-000003.log", "log-000003.log.sha256"},
+		},
+	}
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d. staging prefix: '%s' template: '%s'", index, test.conf.Prefix, test.conf.Template), func(t *testing.T) {
+			defer func() {
+				logErrAt(os.RemoveAll("test"), index, t)
+			}()
+			conf := test.conf
+			conf.Dir = "test"
+			conf.Middle = testCounterMiddle()
+			conf.MaxFiles = 10
+			conf.MaxBytes = 4
+			conf.Checksum = ChecksumFile
+			conf.Staging = ".inprogress"
+			w, err := New(conf)
+			logErrAt(err, index, t)
+			for write := 0; write < 3; write++ {
+				_, err := w.Write([]byte("data"))
+				logErrAt(err, index, t)
+				names := dirNames(t)
+				staged := 0
+				for _, name := range names {
+					if strings.HasSuffix(name, conf.Staging) {
+						staged++
+					}
+				}
+				if staged != 1 || len(names) != write*2+1 {
+					t.Errorf("%d. exp only the current file staged got: %v", index, names)
+				}
+			}
+			logErrAt(w.Close(), index, t)
+			if got := dirNames(t); fmt.Sprint(got) != fmt.Sprint(test.final) {
+				t.Errorf("%d. exp files: %v got: %v", index, test.final, got)
+			}
+		})
+	}
+	conf := DefaultConf()
+	conf.Staging = "/tmp"
+	if err := ValidConf(conf); errStr(err) != "revolver conf.Staging can not contain path separators" {
+		t.Errorf("exp staging err got: %v", err)
+	}
+}
+
+func dirNames(t *testing.T) []string {
+	infos, err := ioutil.ReadDir("test")
+	logErr(err, t)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names
+}
+
 func BenchmarkWriteNew(b *testing.B) {
 	defer func() {
 		logBenchmarkErr(os.RemoveAll("test"), b)